@@ -0,0 +1,231 @@
+// agente.go - Jogadores automáticos (bots) que controlam o personagem no lugar do teclado
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// Distância máxima (em células) em que o agente enxerga outras entidades
+const raioVisaoAgente = 12
+
+// Entidade descreve um elemento do mapa visível ao agente
+type Entidade struct {
+	Tipo string `json:"type"` // "inimigo", "fantasma", "tesouro", "portal", "armadilha", "armadilha_jogador", "isca", "guardiao", "perseguidor"
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+}
+
+// Observacao é uma cópia somente leitura do estado do jogo entregue aos agentes
+type Observacao struct {
+	Mapa       [][]Elemento // cópia do mapa no momento da observação
+	PosX, PosY int          // posição do personagem
+	Entidades  []Entidade   // entidades dentro do raio de visão
+	StatusMsg  string       // mensagem mais recente do histórico
+	Vida       int          // vidas restantes
+	Pontos     int          // pontuação acumulada
+	Stats      Estatisticas // contadores da partida
+	Terminou   bool         // a partida já acabou
+}
+
+// Agente decide a próxima ação do personagem a partir de uma observação do jogo
+type Agente interface {
+	Decidir(obs Observacao) EventoTeclado
+}
+
+// Agentes disponíveis pela opção -bot, criados a partir de uma semente aleatória
+var agentesDisponiveis = map[string]func(semente int64) Agente{
+	"aleatorio": func(semente int64) Agente { return novoAgenteAleatorio(semente) },
+	"guloso":    func(semente int64) Agente { return novoAgenteGuloso(semente) },
+}
+
+// Nome de cada tipo de entidade, indexado pelo símbolo do elemento no mapa
+var tiposEntidade = map[rune]string{
+	Inimigo.simbolo:   "inimigo",
+	Fantasma.simbolo:  "fantasma",
+	Tesouro.simbolo:   "tesouro",
+	Portal.simbolo:    "portal",
+	Armadilha.simbolo: "armadilha",
+	Laco.simbolo:      "armadilha",
+	Alarme.simbolo:    "armadilha",
+	Teleporte.simbolo: "armadilha",
+	Guardian.simbolo:  "guardiao",
+
+	ArmadilhaGasta.simbolo:   "armadilha",
+	ArmadilhaJogador.simbolo: "armadilha_jogador",
+	Isca.simbolo:             "isca",
+	Perseguidor.simbolo:      "perseguidor",
+}
+
+// Direções de movimento disponíveis ao agente e a tecla correspondente
+var direcoesAgente = []struct {
+	tecla  rune
+	dx, dy int
+}{
+	{'w', 0, -1},
+	{'a', -1, 0},
+	{'s', 0, 1},
+	{'d', 1, 0},
+}
+
+// Tira uma fotografia do estado atual do jogo para ser entregue a um agente,
+// a partir de um instantâneo do dono do jogo. As linhas do instantâneo são
+// compartilhadas com o desenho, então o agente recebe uma cópia delas.
+func jogoObservar(jogo *Jogo) Observacao {
+	inst := jogoInstantaneo(jogo)
+	if inst == nil {
+		return Observacao{Terminou: true} // partida encerrada
+	}
+
+	mapa := make([][]Elemento, len(inst.Mapa))
+	for y, linha := range inst.Mapa {
+		mapa[y] = slices.Clone(linha)
+	}
+	obs := Observacao{
+		Mapa:      mapa,
+		PosX:      inst.Jogador.X,
+		PosY:      inst.Jogador.Y,
+		StatusMsg: inst.Status,
+		Vida:      inst.Vida,
+		Pontos:    inst.Pontos,
+		Stats:     inst.Stats,
+		Terminou:  inst.Terminou,
+	}
+	for y, linha := range obs.Mapa {
+		for x, elem := range linha {
+			tipo, ok := tiposEntidade[elem.simbolo]
+			if ok && abs(x-obs.PosX) <= raioVisaoAgente && abs(y-obs.PosY) <= raioVisaoAgente {
+				obs.Entidades = append(obs.Entidades, Entidade{Tipo: tipo, X: x, Y: y})
+			}
+		}
+	}
+	return obs
+}
+
+// Verifica, na observação, se o personagem poderia ocupar a posição (x, y)
+func (obs Observacao) livre(x, y int) bool {
+	if y < 0 || y >= len(obs.Mapa) || x < 0 || x >= len(obs.Mapa[y]) {
+		return false
+	}
+	return passavelJogador(x, y, obs.Mapa[y][x])
+}
+
+// Cria uma fonte de eventos que consulta o agente a cada intervalo, mas que
+// ainda permite encerrar o jogo com ESC pelo teclado. A leitura do teclado
+// entra no grupo da partida e para quando ela termina.
+func agenteFonteEventos(agente Agente, jogo *Jogo, intervalo time.Duration) func() EventoTeclado {
+	teclado := make(chan EventoTeclado, 1)
+	jogo.grupo.Iniciar("teclado", func() {
+		for jogo.grupo.Contexto().Err() == nil {
+			if ev := interfaceLerEventoTeclado(); ev.Tipo == "sair" {
+				teclado <- ev
+				return
+			}
+		}
+	})
+
+	return func() EventoTeclado {
+		select {
+		case ev := <-teclado:
+			return ev
+		case <-time.After(intervalo):
+			return agente.Decidir(jogoObservar(jogo))
+		}
+	}
+}
+
+// AgenteAleatorio anda sem rumo, escolhendo a cada passo uma direção livre
+type AgenteAleatorio struct {
+	rng *rand.Rand
+}
+
+func novoAgenteAleatorio(semente int64) *AgenteAleatorio {
+	return &AgenteAleatorio{rng: rand.New(rand.NewSource(semente))}
+}
+
+func (a *AgenteAleatorio) Decidir(obs Observacao) EventoTeclado {
+	// De vez em quando tenta interagir com o que estiver por perto
+	if a.rng.Intn(10) == 0 {
+		return EventoTeclado{Tipo: "interagir"}
+	}
+
+	var livres []rune
+	for _, d := range direcoesAgente {
+		if obs.livre(obs.PosX+d.dx, obs.PosY+d.dy) {
+			livres = append(livres, d.tecla)
+		}
+	}
+	if len(livres) == 0 {
+		return EventoTeclado{}
+	}
+	return EventoTeclado{Tipo: "mover", Tecla: livres[a.rng.Intn(len(livres))]}
+}
+
+// AgenteGuloso vai sempre em direção ao tesouro visível mais próximo e
+// perambula aleatoriamente quando não enxerga nenhum
+type AgenteGuloso struct {
+	rng        *rand.Rand
+	antX, antY int // posição anterior, evita ir e voltar entre duas células
+	errante    *AgenteAleatorio
+}
+
+func novoAgenteGuloso(semente int64) *AgenteGuloso {
+	return &AgenteGuloso{
+		rng:     rand.New(rand.NewSource(semente)),
+		antX:    -1,
+		antY:    -1,
+		errante: novoAgenteAleatorio(semente + 1),
+	}
+}
+
+func (a *AgenteGuloso) Decidir(obs Observacao) EventoTeclado {
+	alvo, achou := tesouroMaisProximo(obs)
+	if !achou {
+		return a.errante.Decidir(obs)
+	}
+
+	// Já está sobre o tesouro: coleta
+	if alvo.X == obs.PosX && alvo.Y == obs.PosY {
+		return EventoTeclado{Tipo: "interagir"}
+	}
+
+	// Escolhe o passo livre que mais reduz a distância até o tesouro
+	melhor := rune(0)
+	melhorDist := 0
+	for _, d := range direcoesAgente {
+		nx, ny := obs.PosX+d.dx, obs.PosY+d.dy
+		if !obs.livre(nx, ny) || (nx == a.antX && ny == a.antY) {
+			continue
+		}
+		dist := abs(alvo.X-nx) + abs(alvo.Y-ny)
+		if melhor == 0 || dist < melhorDist || (dist == melhorDist && a.rng.Intn(2) == 0) {
+			melhor, melhorDist = d.tecla, dist
+		}
+	}
+
+	a.antX, a.antY = obs.PosX, obs.PosY
+	if melhor == 0 {
+		// Encurralado: volta por onde veio na próxima vez
+		a.antX, a.antY = -1, -1
+		return a.errante.Decidir(obs)
+	}
+	return EventoTeclado{Tipo: "mover", Tecla: melhor}
+}
+
+// Procura, entre as entidades observadas, o tesouro mais próximo do personagem
+func tesouroMaisProximo(obs Observacao) (Entidade, bool) {
+	var alvo Entidade
+	achou := false
+	melhorDist := 0
+	for _, e := range obs.Entidades {
+		if e.Tipo != "tesouro" {
+			continue
+		}
+		dist := abs(e.X-obs.PosX) + abs(e.Y-obs.PosY)
+		if !achou || dist < melhorDist {
+			alvo, melhorDist, achou = e, dist, true
+		}
+	}
+	return alvo, achou
+}
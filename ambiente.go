@@ -0,0 +1,259 @@
+// ambiente.go - Ambiente de aprendizado por reforço: protocolo JSON por linhas em stdin/stdout
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// Comando recebido pelo ambiente, um objeto JSON por linha:
+//
+//	{"cmd": "reset", "seed": 42, "map": "mapa.txt"}
+//	{"cmd": "step", "action": "up"}
+//	{"cmd": "observe"}
+//	{"cmd": "close"}
+type comandoAmbiente struct {
+	Cmd      string `json:"cmd"`
+	Seed     int64  `json:"seed"`
+	Map      string `json:"map"`
+	Action   string `json:"action"`
+	RealTime bool   `json:"real_time"` // elementos correm em tempo real em vez de travados no step
+	DtMs     int    `json:"dt_ms"`     // tempo de jogo que cada step representa
+	MaxSteps int    `json:"max_steps"` // encerra o episódio após este número de steps (0 = sem limite)
+	Config   string `json:"config"`    // arquivo de configuração (padrão: config.json, se existir)
+}
+
+// Resposta do ambiente, também um objeto JSON por linha
+type respostaAmbiente struct {
+	Obs    *observacaoAmbiente `json:"obs,omitempty"`
+	Reward float64             `json:"reward"`
+	Done   bool                `json:"done"`
+	Info   map[string]any      `json:"info,omitempty"`
+	Error  string              `json:"error,omitempty"`  // um dos códigos erroAmbiente*
+	Detail string              `json:"detail,omitempty"` // explicação do erro, em texto livre
+}
+
+// Códigos do campo error. São estáveis e em inglês, como os demais campos do
+// protocolo, para que os clientes possam compará-los; o texto que explica o
+// erro vai em detail.
+const (
+	erroAmbienteComandoInvalido     = "invalid_command"
+	erroAmbienteComandoDesconhecido = "unknown_command"
+	erroAmbienteSemEpisodio         = "no_episode"
+	erroAmbienteEpisodioEncerrado   = "episode_done"
+	erroAmbienteAcaoDesconhecida    = "unknown_action"
+	erroAmbienteReset               = "reset_failed"
+)
+
+// Observação serializada: o mapa como linhas de texto, com o personagem desenhado
+type observacaoAmbiente struct {
+	Map      []string   `json:"map"`
+	X        int        `json:"x"`
+	Y        int        `json:"y"`
+	Entities []Entidade `json:"entities"`
+	Status   string     `json:"status"`
+	Lives    int        `json:"lives"`
+	Score    int        `json:"score"`
+}
+
+// Ações aceitas pelo comando step
+var acoesAmbiente = map[string]EventoTeclado{
+	"up":       {Tipo: "mover", Tecla: 'w'},
+	"left":     {Tipo: "mover", Tecla: 'a'},
+	"down":     {Tipo: "mover", Tecla: 's'},
+	"right":    {Tipo: "mover", Tecla: 'd'},
+	"w":        {Tipo: "mover", Tecla: 'w'},
+	"a":        {Tipo: "mover", Tecla: 'a'},
+	"s":        {Tipo: "mover", Tecla: 's'},
+	"d":        {Tipo: "mover", Tecla: 'd'},
+	"interact": {Tipo: "interagir"},
+	"e":        {Tipo: "interagir"},
+	"trap":     {Tipo: "armar"},
+	"lure":     {Tipo: "isca"},
+	"wait":     {},
+	"":         {},
+}
+
+// Episódio em andamento no ambiente
+type ambiente struct {
+	partida   *Partida
+	dt        time.Duration
+	passos    int
+	maxPassos int
+	pontos    int  // pontuação na última resposta, base da recompensa
+	terminou  bool // o episódio acabou e precisa de um reset
+}
+
+// Lê comandos da entrada e escreve as respostas na saída até receber "close"
+func ambienteExecutar(entrada io.Reader, saida io.Writer) error {
+	amb := &ambiente{}
+	defer ambienteFechar(amb)
+
+	scanner := bufio.NewScanner(entrada)
+	codificador := json.NewEncoder(saida)
+	for scanner.Scan() {
+		linha := strings.TrimSpace(scanner.Text())
+		if linha == "" {
+			continue
+		}
+
+		var cmd comandoAmbiente
+		var resp respostaAmbiente
+		if err := json.Unmarshal([]byte(linha), &cmd); err != nil {
+			resp = respostaAmbiente{Error: erroAmbienteComandoInvalido, Detail: err.Error()}
+		} else {
+			resp = ambienteTratar(amb, cmd)
+		}
+		if err := codificador.Encode(resp); err != nil {
+			return err
+		}
+		if cmd.Cmd == "close" {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// Executa um comando e monta a resposta correspondente
+func ambienteTratar(amb *ambiente, cmd comandoAmbiente) respostaAmbiente {
+	switch cmd.Cmd {
+	case "reset":
+		return ambienteReset(amb, cmd)
+	case "step":
+		return ambienteStep(amb, cmd.Action)
+	case "observe":
+		if amb.partida == nil {
+			return respostaAmbiente{Error: erroAmbienteSemEpisodio, Detail: "send reset first"}
+		}
+		return ambienteResposta(amb, jogoObservar(amb.partida.Jogo), 0)
+	case "close":
+		ambienteFechar(amb)
+		return respostaAmbiente{Done: true}
+	}
+	return respostaAmbiente{Error: erroAmbienteComandoDesconhecido, Detail: cmd.Cmd}
+}
+
+// Encerra o episódio anterior e começa um novo
+func ambienteReset(amb *ambiente, cmd comandoAmbiente) respostaAmbiente {
+	ambienteFechar(amb)
+
+	mapaFile := cmd.Map
+	if mapaFile == "" {
+		mapaFile = "mapa.txt"
+	}
+	amb.dt = 100 * time.Millisecond
+	if cmd.DtMs > 0 {
+		amb.dt = time.Duration(cmd.DtMs) * time.Millisecond
+	}
+
+	config, err := configCarregar(cmd.Config, mapaFile)
+	if err != nil {
+		return respostaAmbiente{Error: erroAmbienteReset, Detail: err.Error()}
+	}
+	partida, err := partidaNova(context.Background(), mapaFile, OpcoesPartida{
+		Semente:     cmd.Seed,
+		PassoAPasso: !cmd.RealTime,
+		Quantum:     amb.dt,
+		SemTela:     true,
+		Config:      config,
+	})
+	if err != nil {
+		return respostaAmbiente{Error: erroAmbienteReset, Detail: err.Error()}
+	}
+
+	amb.partida = partida
+	amb.passos = 0
+	amb.maxPassos = cmd.MaxSteps
+	amb.terminou = false
+	obs := jogoObservar(partida.Jogo)
+	amb.pontos = obs.Pontos
+	return ambienteResposta(amb, obs, 0)
+}
+
+// Aplica a ação do jogador e avança o jogo por um passo
+func ambienteStep(amb *ambiente, acao string) respostaAmbiente {
+	if amb.partida == nil {
+		return respostaAmbiente{Error: erroAmbienteSemEpisodio, Detail: "send reset first"}
+	}
+	if amb.terminou {
+		return respostaAmbiente{Error: erroAmbienteEpisodioEncerrado, Detail: "send reset to start a new episode"}
+	}
+	ev, ok := acoesAmbiente[acao]
+	if !ok {
+		return respostaAmbiente{Error: erroAmbienteAcaoDesconhecida, Detail: acao}
+	}
+
+	partidaExecutar(amb.partida, ev)
+	if amb.partida.Jogo.relogio.passoAPasso {
+		partidaAvancar(amb.partida, amb.dt)
+	} else {
+		time.Sleep(amb.dt)
+	}
+	amb.passos++
+
+	obs := jogoObservar(amb.partida.Jogo)
+	recompensa := float64(obs.Pontos - amb.pontos)
+	amb.pontos = obs.Pontos
+	return ambienteResposta(amb, obs, recompensa)
+}
+
+// Monta a resposta com a observação, a recompensa e as informações extras
+func ambienteResposta(amb *ambiente, obs Observacao, recompensa float64) respostaAmbiente {
+	truncado := amb.maxPassos > 0 && amb.passos >= amb.maxPassos
+	amb.terminou = obs.Terminou || truncado
+
+	linhas := make([]string, len(obs.Mapa))
+	for y, linha := range obs.Mapa {
+		runas := make([]rune, len(linha))
+		for x, elem := range linha {
+			runas[x] = elem.simbolo
+		}
+		if y == obs.PosY && obs.PosX >= 0 && obs.PosX < len(runas) {
+			runas[obs.PosX] = Personagem.simbolo
+		}
+		linhas[y] = string(runas)
+	}
+
+	entidades := obs.Entidades
+	if entidades == nil {
+		entidades = []Entidade{}
+	}
+
+	return respostaAmbiente{
+		Obs: &observacaoAmbiente{
+			Map:      linhas,
+			X:        obs.PosX,
+			Y:        obs.PosY,
+			Entities: entidades,
+			Status:   obs.StatusMsg,
+			Lives:    obs.Vida,
+			Score:    obs.Pontos,
+		},
+		Reward: recompensa,
+		Done:   amb.terminou,
+		Info: map[string]any{
+			"step":           amb.passos,
+			"time_ms":        amb.partida.Jogo.relogio.Agora().Milliseconds(),
+			"treasures":      obs.Stats.TesourosColetados,
+			"trap_hits":      obs.Stats.ArmadilhasAtingidas,
+			"portal_uses":    obs.Stats.PortaisUsados,
+			"ghost_catches":  obs.Stats.CapturasFantasma,
+			"swarm_catches":  obs.Stats.CapturasEnxame,
+			"guardian_hits":  obs.Stats.AtaquesGuardiao,
+			"truncated":      truncado && !obs.Terminou,
+			"events_dropped": amb.partida.Jogo.eventos.Descartados(),
+		},
+	}
+}
+
+// Encerra as goroutines do episódio atual, se houver
+func ambienteFechar(amb *ambiente) {
+	if amb.partida != nil {
+		partidaEncerrar(amb.partida)
+		amb.partida = nil
+	}
+}
@@ -0,0 +1,269 @@
+// armadilhas.go - Tipos de armadilha, seus efeitos e o registro das armadilhas no mapa
+package main
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Tipos de armadilha; cada um tem seu elemento no mapa e seu efeito
+type TipoArmadilha int
+
+const (
+	ArmadilhaEspinhos  TipoArmadilha = iota // tira uma vida e pontos
+	ArmadilhaLaco                           // prende o jogador no lugar por um tempo
+	ArmadilhaAlarme                         // acorda o guardião, que sabe onde o jogador está
+	ArmadilhaTeleporte                      // leva o jogador para outro lugar do mapa
+)
+
+// Tipos sorteados pelo controle central
+var tiposArmadilha = []TipoArmadilha{ArmadilhaEspinhos, ArmadilhaLaco, ArmadilhaAlarme, ArmadilhaTeleporte}
+
+func (t TipoArmadilha) String() string {
+	switch t {
+	case ArmadilhaLaco:
+		return "laco"
+	case ArmadilhaAlarme:
+		return "alarme"
+	case ArmadilhaTeleporte:
+		return "teleporte"
+	}
+	return "espinhos"
+}
+
+// Nome do tipo no idioma em uso
+func (t TipoArmadilha) Nome() string {
+	return traduzir("armadilha.nome." + t.String())
+}
+
+// Grava o tipo pelo nome no diário e no salvamento
+func (t TipoArmadilha) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Lê o tipo pelo nome, ao carregar um salvamento
+func (t *TipoArmadilha) UnmarshalText(texto []byte) error {
+	for _, tipo := range tiposArmadilha {
+		if tipo.String() == string(texto) {
+			*t = tipo
+			return nil
+		}
+	}
+	return erroTraduzido("erro.salvamento_armadilha", string(texto))
+}
+
+// Elemento que mostra a armadilha armada no mapa
+func (t TipoArmadilha) Elemento() Elemento {
+	switch t {
+	case ArmadilhaLaco:
+		return Laco
+	case ArmadilhaAlarme:
+		return Alarme
+	case ArmadilhaTeleporte:
+		return Teleporte
+	}
+	return Armadilha
+}
+
+// Tempo até a armadilha voltar a funcionar depois de disparar
+func (t TipoArmadilha) Rearme(cfg *Configuracao) time.Duration {
+	r := cfg.Armadilha.Rearme
+	switch t {
+	case ArmadilhaLaco:
+		return r.Laco.Tempo()
+	case ArmadilhaAlarme:
+		return r.Alarme.Tempo()
+	case ArmadilhaTeleporte:
+		return r.Teleporte.Tempo()
+	}
+	return r.Espinhos.Tempo()
+}
+
+// ArmadilhaInstalada é uma armadilha no mapa. O registro fica no jogo porque
+// as escondidas não aparecem no mapa e as disparadas mostram outro elemento.
+type ArmadilhaInstalada struct {
+	Pos       Ponto
+	Tipo      TipoArmadilha
+	Oculta    bool // não aparece no mapa até ser encontrada ou disparar
+	Armada    bool // false enquanto espera o rearme depois de disparar
+	DoJogador bool // colocada pelo jogador: atordoa o primeiro elemento que pisar nela
+
+	Expira time.Duration // instante em que sai do mapa sozinha; zero nas do jogador, que não expiram
+	Rearme time.Duration // instante em que volta a armar, enquanto espera o rearme
+}
+
+// Indica se o elemento é uma armadilha visível, armada ou esperando o rearme
+func elementoArmadilha(e Elemento) bool {
+	switch e.simbolo {
+	case Armadilha.simbolo, Laco.simbolo, Alarme.simbolo, Teleporte.simbolo, ArmadilhaGasta.simbolo, ArmadilhaJogador.simbolo:
+		return true
+	}
+	return false
+}
+
+// Elemento que mostra a armadilha armada no mapa
+func (a *ArmadilhaInstalada) Elemento() Elemento {
+	if a.DoJogador {
+		return ArmadilhaJogador
+	}
+	return a.Tipo.Elemento()
+}
+
+// Instala a armadilha, já armada, na sua posição. As escondidas deixam o
+// mapa como está. (chamada pelo dono do jogo)
+func armadilhaInstalar(jogo *Jogo, a *ArmadilhaInstalada) *ArmadilhaInstalada {
+	a.Armada = true
+	jogo.armadilhas[a.Pos] = a
+	if !a.Oculta {
+		jogo.Mapa[a.Pos.Y][a.Pos.X] = a.Elemento()
+	}
+	return a
+}
+
+// Indica se o jogador pode colocar uma armadilha ou isca na posição: uma
+// célula vazia, fora do personagem e sem outra armadilha ou isca visível. As
+// escondidas não contam, para não serem descobertas assim; quem coloca algo
+// sobre uma delas a dispara. (chamada pelo dono do jogo)
+func armadilhaPodeColocar(jogo *Jogo, p Ponto) bool {
+	if !dentroDoMapa(jogo.Mapa, p) || p == (Ponto{jogo.PosX, jogo.PosY}) {
+		return false
+	}
+	_, temIsca := jogo.iscas[p]
+	a := jogo.armadilhas[p]
+	return jogo.Mapa[p.Y][p.X].simbolo == Vazio.simbolo && (a == nil || a.Oculta) && !temIsca
+}
+
+// Põe o elemento na célula e retorna o que havia nela. Quem entra numa
+// armadilha do jogador a gasta e fica atordoado; o atordoamento é guardado
+// pelo nome de quem pisou, e não pela célula, para acompanhá-lo se ele sair
+// dali de outro jeito. (chamada pelo dono do jogo)
+func jogoOcuparCelula(jogo *Jogo, p Ponto, e Elemento, quem string) Elemento {
+	sob := jogo.Mapa[p.Y][p.X]
+	jogo.Mapa[p.Y][p.X] = e
+	if a := jogo.armadilhas[p]; a != nil && a.DoJogador {
+		delete(jogo.armadilhas, p)
+		jogo.atordoados[quem] = jogo.relogio.Agora() + jogo.config.Armadilha.Atordoamento.Tempo()
+		alvo := chavesElemento[e.simbolo]
+		jogoInformarEstado(jogo, "armadilha", p, "estado.atordoou", personagemDescrever(jogo, p))
+		jogoMensagem(jogo, GravidadeInfo, "armadilha", "armadilha.atordoou", personagemDescrever(jogo, p))
+		jogoPublicar(jogo, ArmadilhaDisparada{Pos: p, Armadilha: a.Tipo, Alvo: alvo})
+	}
+	return sob
+}
+
+// Devolve à célula que um elemento deixou o que havia nela, menos as
+// armadilhas e iscas do jogador que já saíram do mapa (chamada pelo dono do
+// jogo)
+func jogoDeixarCelula(jogo *Jogo, p Ponto, sob Elemento) {
+	_, temIsca := jogo.iscas[p]
+	if (sob.simbolo == ArmadilhaJogador.simbolo && jogo.armadilhas[p] == nil) || (sob.simbolo == Isca.simbolo && !temIsca) {
+		sob = Vazio
+	}
+	jogo.Mapa[p.Y][p.X] = sob
+}
+
+// Indica se o elemento com o nome indicado está atordoado por uma armadilha
+// do jogador (chamada pelo dono do jogo)
+func jogoAtordoado(jogo *Jogo, quem string) bool {
+	ate, ok := jogo.atordoados[quem]
+	if ok && jogo.relogio.Agora() >= ate {
+		delete(jogo.atordoados, quem)
+		return false
+	}
+	return ok
+}
+
+// Isca mais próxima da posição, até a distância indicada. Empates ficam com
+// a de cima e depois a da esquerda, para que a escolha não dependa da ordem
+// do mapa de iscas. (chamada pelo dono do jogo)
+func jogoIscaProxima(jogo *Jogo, p Ponto, raio int) (Ponto, bool) {
+	melhor, achou := Ponto{}, false
+	for isca := range jogo.iscas {
+		d := heuristicaManhattan(p, isca)
+		if d > raio {
+			continue
+		}
+		if dm := heuristicaManhattan(p, melhor); !achou || d < dm || (d == dm && (isca.Y < melhor.Y || (isca.Y == melhor.Y && isca.X < melhor.X))) {
+			melhor, achou = isca, true
+		}
+	}
+	return melhor, achou
+}
+
+// Tira a armadilha do registro e do mapa (chamada pelo dono do jogo)
+func armadilhaRemover(jogo *Jogo, pos Ponto) {
+	delete(jogo.armadilhas, pos)
+	if elementoArmadilha(jogo.Mapa[pos.Y][pos.X]) {
+		jogo.Mapa[pos.Y][pos.X] = Vazio
+	}
+}
+
+// O jogador pisou na posição: dispara a armadilha armada que houver nela e a
+// retorna, revelada e esperando o rearme (chamada pelo dono do jogo)
+func armadilhaPisar(jogo *Jogo, pos Ponto, rng *rand.Rand) *ArmadilhaInstalada {
+	a := jogo.armadilhas[pos]
+	if a == nil || !a.Armada || a.DoJogador || jogoTerminou(jogo) {
+		return nil
+	}
+	a.Armada, a.Oculta = false, false
+	a.Rearme = jogo.relogio.Agora() + a.Tipo.Rearme(jogo.config)
+	if c := jogo.Mapa[pos.Y][pos.X]; c.simbolo == Vazio.simbolo || elementoArmadilha(c) {
+		jogo.Mapa[pos.Y][pos.X] = ArmadilhaGasta
+	}
+	armadilhaDisparar(jogo, a.Tipo, pos, rng)
+	return a
+}
+
+// Volta a armar a armadilha que disparou (chamada pelo dono do jogo)
+func armadilhaRearmar(jogo *Jogo, a *ArmadilhaInstalada) {
+	a.Armada = true
+	if jogo.Mapa[a.Pos.Y][a.Pos.X].simbolo == ArmadilhaGasta.simbolo {
+		jogo.Mapa[a.Pos.Y][a.Pos.X] = a.Elemento()
+	}
+	jogoInformarEstado(jogo, "armadilha", a.Pos, "estado.rearmou", a.Tipo.Nome())
+}
+
+// Aplica no jogador o efeito da armadilha (chamada pelo dono do jogo)
+func armadilhaDisparar(jogo *Jogo, tipo TipoArmadilha, pos Ponto, rng *rand.Rand) {
+	jogoInformarEstado(jogo, "armadilha", pos, "estado.disparou", tipo.Nome())
+	jogo.Stats.ArmadilhasAtingidas++
+	jogoPublicar(jogo, ArmadilhaDisparada{Pos: pos, Armadilha: tipo, Alvo: "personagem"})
+
+	switch tipo {
+	case ArmadilhaEspinhos:
+		jogoMensagem(jogo, GravidadePerigo, "armadilha", "armadilha.espinhos")
+		jogoFerirPersonagem(jogo, "armadilha", jogo.config.Armadilha.Penalidade)
+	case ArmadilhaLaco:
+		jogoMensagem(jogo, GravidadeAviso, "armadilha", "armadilha.laco")
+		jogo.presoAte = jogo.relogio.Agora() + jogo.config.Armadilha.Laco.Tempo()
+		jogo.caminhoJogador = nil
+	case ArmadilhaAlarme:
+		jogoMensagem(jogo, GravidadePerigo, "armadilha", "armadilha.alarme")
+		jogoPublicar(jogo, OrdemGuardiao{Jogador: Ponto{jogo.PosX, jogo.PosY}, Alerta: true})
+	case ArmadilhaTeleporte:
+		jogoMensagem(jogo, GravidadeAviso, "armadilha", "armadilha.teleporte")
+		de := Ponto{jogo.PosX, jogo.PosY}
+		jogoPublicar(jogo, JogadorMoveu{De: de, Para: jogoTeletransportar(jogo, rng)})
+	}
+}
+
+// Revela as armadilhas escondidas perto do personagem e retorna quantas
+// encontrou. Só aparecem as que estão em células vazias; as outras continuam
+// escondidas. (chamada pelo dono do jogo)
+func armadilhaProcurar(jogo *Jogo) int {
+	raio := jogo.config.Armadilha.Busca
+	encontradas := 0
+	for y := jogo.PosY - raio; y <= jogo.PosY+raio; y++ {
+		for x := jogo.PosX - raio; x <= jogo.PosX+raio; x++ {
+			a := jogo.armadilhas[Ponto{x, y}]
+			if a == nil || !a.Oculta || jogo.Mapa[y][x].simbolo != Vazio.simbolo {
+				continue
+			}
+			a.Oculta = false
+			jogo.Mapa[y][x] = a.Elemento()
+			jogoInformarEstado(jogo, "armadilha", a.Pos, "estado.encontrada", a.Tipo.Nome())
+			encontradas++
+		}
+	}
+	return encontradas
+}
@@ -0,0 +1,182 @@
+// barramento.go - Barramento de eventos: publicação e inscrição entre os elementos
+package main
+
+import "sort"
+
+// Barramento distribui os eventos publicados para todos os inscritos
+// interessados. A publicação nunca bloqueia: cada inscrito tem a sua fila de
+// eventos pendentes, preenchida na hora pelo publicador, e é avisado pelo
+// canal C de que há algo para ler. Por isso o dono do jogo pode publicar
+// enquanto aplica uma intenção, e no modo passo a passo todo evento publicado antes de um
+// pulso de mensagens já está na fila quando o inscrito a esvazia.
+type Barramento struct {
+	acesso     chan bool // exclusão mútua das inscrições e filas
+	inscricoes []*Inscricao
+
+	// Chamado a cada publicação, com o barramento travado, com os nomes dos
+	// inscritos que receberam o evento e dos que o perderam por fila cheia
+	observador func(ev Evento, entregue, descartado []string)
+}
+
+// OpcoesInscricao escolhe quais eventos um inscrito recebe e como
+type OpcoesInscricao struct {
+	Nome  string       // identifica o inscrito no relatório
+	Tipos []TipoEvento // tipos de evento aceitos (vazio aceita todos)
+
+	// Filtro adicional, chamado pelo publicador com o barramento travado:
+	// não deve enviar intenções ao dono do jogo nem publicar eventos
+	Filtro func(ev Evento) bool
+
+	// Máximo de eventos pendentes. Com 0 a entrega é garantida e a fila não
+	// tem limite; com um valor positivo a entrega é de melhor esforço e os
+	// eventos que chegam com a fila cheia são descartados e contados.
+	Capacidade int
+}
+
+// Inscricao é a caixa de entrada de um inscrito no barramento
+type Inscricao struct {
+	C <-chan bool // recebe um sinal quando há eventos pendentes
+
+	opcoes      OpcoesInscricao
+	tipos       map[TipoEvento]bool
+	aviso       chan bool
+	pendentes   []Evento
+	entregues   int
+	descartados int
+	barramento  *Barramento
+}
+
+// RelatorioInscricao resume as entregas feitas a um inscrito
+type RelatorioInscricao struct {
+	Nome        string
+	Garantida   bool // entrega garantida (fila sem limite) ou de melhor esforço
+	Capacidade  int  // máximo de eventos pendentes, se a entrega é de melhor esforço
+	Entregues   int  // eventos colocados na fila do inscrito
+	Descartados int  // eventos perdidos por fila cheia
+	Pendentes   int  // eventos ainda não lidos
+}
+
+// Cria um barramento sem inscritos
+func barramentoNovo() *Barramento {
+	b := &Barramento{acesso: make(chan bool, 1)}
+	b.acesso <- true
+	return b
+}
+
+// Inscreve um novo interessado nos eventos escolhidos pelas opções
+func (b *Barramento) Inscrever(opcoes OpcoesInscricao) *Inscricao {
+	aviso := make(chan bool, 1)
+	insc := &Inscricao{C: aviso, opcoes: opcoes, aviso: aviso, barramento: b}
+	if len(opcoes.Tipos) > 0 {
+		insc.tipos = make(map[TipoEvento]bool)
+		for _, t := range opcoes.Tipos {
+			insc.tipos[t] = true
+		}
+	}
+
+	<-b.acesso
+	b.inscricoes = append(b.inscricoes, insc)
+	b.acesso <- true
+	return insc
+}
+
+// Entrega o evento a todos os inscritos interessados, sem bloquear
+func (b *Barramento) Publicar(ev Evento) {
+	<-b.acesso
+	defer func() { b.acesso <- true }()
+
+	var entregue, descartado []string
+	for _, insc := range b.inscricoes {
+		if insc.tipos != nil && !insc.tipos[ev.Tipo()] {
+			continue
+		}
+		if insc.opcoes.Filtro != nil && !insc.opcoes.Filtro(ev) {
+			continue
+		}
+		if insc.opcoes.Capacidade > 0 && len(insc.pendentes) >= insc.opcoes.Capacidade {
+			insc.descartados++
+			descartado = append(descartado, insc.opcoes.Nome)
+			continue
+		}
+		insc.pendentes = append(insc.pendentes, ev)
+		insc.entregues++
+		entregue = append(entregue, insc.opcoes.Nome)
+		select {
+		case insc.aviso <- true:
+		default: // já havia um aviso pendente
+		}
+	}
+	if b.observador != nil {
+		b.observador(ev, entregue, descartado)
+	}
+}
+
+// Define a função que acompanha todas as publicações, como o diário da
+// sessão. Ela não deve enviar intenções ao dono do jogo nem publicar eventos.
+func (b *Barramento) Observar(observador func(ev Evento, entregue, descartado []string)) {
+	<-b.acesso
+	b.observador = observador
+	b.acesso <- true
+}
+
+// Estado das entregas de cada inscrito, em ordem de nome
+func (b *Barramento) Relatorio() []RelatorioInscricao {
+	<-b.acesso
+	defer func() { b.acesso <- true }()
+
+	relatorio := make([]RelatorioInscricao, 0, len(b.inscricoes))
+	for _, insc := range b.inscricoes {
+		relatorio = append(relatorio, RelatorioInscricao{
+			Nome:        insc.opcoes.Nome,
+			Garantida:   insc.opcoes.Capacidade == 0,
+			Capacidade:  insc.opcoes.Capacidade,
+			Entregues:   insc.entregues,
+			Descartados: insc.descartados,
+			Pendentes:   len(insc.pendentes),
+		})
+	}
+	sort.SliceStable(relatorio, func(i, j int) bool { return relatorio[i].Nome < relatorio[j].Nome })
+	return relatorio
+}
+
+// Total de eventos descartados em todas as inscrições
+func (b *Barramento) Descartados() int {
+	total := 0
+	for _, r := range b.Relatorio() {
+		total += r.Descartados
+	}
+	return total
+}
+
+// Retira da fila todos os eventos pendentes, na ordem em que foram publicados
+func (insc *Inscricao) Receber() []Evento {
+	b := insc.barramento
+	<-b.acesso
+	eventos := insc.pendentes
+	insc.pendentes = nil
+	b.acesso <- true
+	return eventos
+}
+
+// Trata todos os eventos pendentes, fora da trava do barramento para que o
+// tratamento possa enviar intenções ao dono do jogo e publicar novos eventos
+func (insc *Inscricao) Drenar(tratar func(ev Evento)) {
+	for _, ev := range insc.Receber() {
+		tratar(ev)
+	}
+}
+
+// Remove a inscrição; os eventos pendentes são descartados
+func (insc *Inscricao) Cancelar() {
+	b := insc.barramento
+	<-b.acesso
+	defer func() { b.acesso <- true }()
+
+	for i, outra := range b.inscricoes {
+		if outra == insc {
+			b.inscricoes = append(b.inscricoes[:i], b.inscricoes[i+1:]...)
+			break
+		}
+	}
+	insc.pendentes = nil
+}
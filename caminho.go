@@ -0,0 +1,264 @@
+// caminho.go - Busca de caminhos A* sobre o mapa do jogo
+package main
+
+import "container/heap"
+
+// Ponto é uma posição (x, y) no mapa
+type Ponto struct {
+	X, Y int
+}
+
+// Heuristica estima o custo restante entre dois pontos
+type Heuristica func(a, b Ponto) int
+
+// Distância de Manhattan, adequada para movimento nas 4 direções
+func heuristicaManhattan(a, b Ponto) int {
+	return abs(a.X-b.X) + abs(a.Y-b.Y)
+}
+
+// Distância de Chebyshev, adequada quando a diagonal custa o mesmo que um passo reto
+func heuristicaChebyshev(a, b Ponto) int {
+	return max(abs(a.X-b.X), abs(a.Y-b.Y))
+}
+
+// Passavel indica se uma entidade pode ocupar a célula (x, y), que contém o elemento e
+type Passavel func(x, y int, e Elemento) bool
+
+// Passabilidade padrão: qualquer elemento que não bloqueia passagem
+func passavelPadrao(x, y int, e Elemento) bool {
+	return !e.tangivel
+}
+
+// Passabilidade do personagem: além do que não bloqueia passagem, ele pode
+// pisar nas armadilhas
+func passavelJogador(x, y int, e Elemento) bool {
+	return !e.tangivel || elementoArmadilha(e)
+}
+
+// Passabilidade de quem só anda por células vazias ou vegetação, sem pisar
+// em portais, tesouros ou armadilhas. As armadilhas e iscas do jogador
+// passam por chão comum.
+func passavelTerreno(x, y int, e Elemento) bool {
+	switch e.simbolo {
+	case Vazio.simbolo, Vegetacao.simbolo, ArmadilhaJogador.simbolo, Isca.simbolo:
+		return true
+	}
+	return false
+}
+
+// Quantidade de destinos guardados no cache de um buscador
+const tamanhoCacheCaminhos = 32
+
+// Buscador encontra caminhos para uma entidade. Cada entidade deve ter o seu,
+// pois o cache de caminhos não é protegido contra acesso concorrente.
+type Buscador struct {
+	Passavel   Passavel
+	Heuristica Heuristica
+	Diagonais  bool // permite passos na diagonal
+	LimiteNos  int  // máximo de células expandidas por busca (0 = sem limite)
+
+	cache map[Ponto][]Ponto // último caminho encontrado para cada destino, incluindo a origem
+}
+
+// Cria um buscador para movimento nas 4 direções com a heurística de Manhattan
+func buscadorNovo(passavel Passavel) *Buscador {
+	return &Buscador{
+		Passavel:   passavel,
+		Heuristica: heuristicaManhattan,
+		LimiteNos:  5000,
+		cache:      make(map[Ponto][]Ponto),
+	}
+}
+
+// Deslocamentos possíveis a partir de uma célula
+var (
+	vizinhosRetos     = []Ponto{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+	vizinhosDiagonais = []Ponto{{0, -1}, {1, 0}, {0, 1}, {-1, 0}, {1, -1}, {1, 1}, {-1, 1}, {-1, -1}}
+)
+
+// Retorna o caminho de origem até destino, sem a origem e com o destino.
+// O destino é sempre aceito, mesmo ocupado, para que se possa caminhar até
+// outra entidade. Retorna nil se não houver caminho.
+func (b *Buscador) Caminho(mapa [][]Elemento, origem, destino Ponto) []Ponto {
+	if origem == destino || !dentroDoMapa(mapa, destino) {
+		return nil
+	}
+	if caminho := b.doCache(mapa, origem, destino); caminho != nil {
+		return caminho
+	}
+
+	caminho := b.aEstrela(mapa, origem, destino)
+	if caminho == nil {
+		return nil
+	}
+	if len(b.cache) >= tamanhoCacheCaminhos {
+		clear(b.cache)
+	}
+	b.cache[destino] = caminho
+	return caminho[1:]
+}
+
+// Retorna apenas o primeiro passo do caminho até o destino
+func (b *Buscador) ProximoPasso(mapa [][]Elemento, origem, destino Ponto) (Ponto, bool) {
+	caminho := b.Caminho(mapa, origem, destino)
+	if len(caminho) == 0 {
+		return origem, false
+	}
+	return caminho[0], true
+}
+
+// Reaproveita um caminho já calculado para o mesmo destino se a origem estiver
+// nele e o trecho restante continuar livre
+func (b *Buscador) doCache(mapa [][]Elemento, origem, destino Ponto) []Ponto {
+	anterior, ok := b.cache[destino]
+	if !ok {
+		return nil
+	}
+	for i, p := range anterior {
+		if p != origem {
+			continue
+		}
+		resto := anterior[i+1:]
+		for _, q := range resto[:len(resto)-1] {
+			if !b.Passavel(q.X, q.Y, mapa[q.Y][q.X]) {
+				delete(b.cache, destino)
+				return nil
+			}
+		}
+		return resto
+	}
+	return nil
+}
+
+// Busca A* propriamente dita; retorna o caminho incluindo a origem
+func (b *Buscador) aEstrela(mapa [][]Elemento, origem, destino Ponto) []Ponto {
+	vizinhos := vizinhosRetos
+	if b.Diagonais {
+		vizinhos = vizinhosDiagonais
+	}
+
+	custo := map[Ponto]int{origem: 0}
+	veioDe := make(map[Ponto]Ponto)
+	abertos := &filaPrioridade{}
+	heap.Push(abertos, noBusca{origem, b.Heuristica(origem, destino)})
+
+	expandidos := 0
+	for abertos.Len() > 0 {
+		atual := heap.Pop(abertos).(noBusca).ponto
+		if atual == destino {
+			return reconstruirCaminho(veioDe, origem, destino)
+		}
+		expandidos++
+		if b.LimiteNos > 0 && expandidos > b.LimiteNos {
+			return nil
+		}
+
+		for _, d := range vizinhos {
+			prox := Ponto{atual.X + d.X, atual.Y + d.Y}
+			if !dentroDoMapa(mapa, prox) {
+				continue
+			}
+			if prox != destino && !b.Passavel(prox.X, prox.Y, mapa[prox.Y][prox.X]) {
+				continue
+			}
+			novoCusto := custo[atual] + 1
+			if c, visto := custo[prox]; visto && c <= novoCusto {
+				continue
+			}
+			custo[prox] = novoCusto
+			veioDe[prox] = atual
+			heap.Push(abertos, noBusca{prox, novoCusto + b.Heuristica(prox, destino)})
+		}
+	}
+	return nil
+}
+
+// Refaz o caminho seguindo de trás para frente a partir do destino
+func reconstruirCaminho(veioDe map[Ponto]Ponto, origem, destino Ponto) []Ponto {
+	caminho := []Ponto{destino}
+	for p := destino; p != origem; {
+		p = veioDe[p]
+		caminho = append(caminho, p)
+	}
+	for i, j := 0, len(caminho)-1; i < j; i, j = i+1, j-1 {
+		caminho[i], caminho[j] = caminho[j], caminho[i]
+	}
+	return caminho
+}
+
+// Verifica se o ponto está dentro do mapa (as linhas podem ter tamanhos diferentes)
+func dentroDoMapa(mapa [][]Elemento, p Ponto) bool {
+	return p.Y >= 0 && p.Y < len(mapa) && p.X >= 0 && p.X < len(mapa[p.Y])
+}
+
+// Célula aberta na busca, ordenada pelo custo estimado total
+type noBusca struct {
+	ponto Ponto
+	f     int
+}
+
+// Fila de prioridade de células abertas (heap mínimo por f)
+type filaPrioridade []noBusca
+
+func (f filaPrioridade) Len() int           { return len(f) }
+func (f filaPrioridade) Less(i, j int) bool { return f[i].f < f[j].f }
+func (f filaPrioridade) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+func (f *filaPrioridade) Push(x any)        { *f = append(*f, x.(noBusca)) }
+func (f *filaPrioridade) Pop() any {
+	antiga := *f
+	n := len(antiga)
+	item := antiga[n-1]
+	*f = antiga[:n-1]
+	return item
+}
+
+// Prevê onde o alvo estará seguindo a direção do seu último movimento, para
+// que o perseguidor corte caminho em vez de apenas ir atrás dele. A previsão
+// avança metade da distância atual e para antes de células bloqueadas.
+func pontoInterceptacao(mapa [][]Elemento, perseguidor, alvo, anterior Ponto) Ponto {
+	dx, dy := sinal(alvo.X-anterior.X), sinal(alvo.Y-anterior.Y)
+	p := alvo
+	for i := heuristicaManhattan(perseguidor, alvo) / 2; i > 0; i-- {
+		prox := Ponto{p.X + dx, p.Y + dy}
+		if prox == p || !dentroDoMapa(mapa, prox) || mapa[prox.Y][prox.X].tangivel {
+			break
+		}
+		p = prox
+	}
+	return p
+}
+
+// Sinal de um inteiro: -1, 0 ou 1
+func sinal(x int) int {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+// Verifica se há linha de visão entre dois pontos, isto é, se nenhuma parede
+// está no segmento entre eles (algoritmo de Bresenham)
+func linhaDeVisao(mapa [][]Elemento, a, b Ponto) bool {
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := sinal(b.X-a.X), sinal(b.Y-a.Y)
+	erro := dx + dy
+	p := a
+	for p != b {
+		e2 := 2 * erro
+		if e2 >= dy {
+			erro += dy
+			p.X += sx
+		}
+		if e2 <= dx {
+			erro += dx
+			p.Y += sy
+		}
+		if p != b && dentroDoMapa(mapa, p) && mapa[p.Y][p.X].simbolo == Parede.simbolo {
+			return false
+		}
+	}
+	return true
+}
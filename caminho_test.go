@@ -0,0 +1,152 @@
+package main
+
+import "testing"
+
+// Monta um mapa a partir de linhas de texto: '#' é parede, '"' é vegetação e
+// qualquer outro caractere é uma célula vazia
+func mapaTeste(linhas ...string) [][]Elemento {
+	mapa := make([][]Elemento, len(linhas))
+	for y, linha := range linhas {
+		for _, c := range linha {
+			e := Vazio
+			switch c {
+			case '#':
+				e = Parede
+			case '"':
+				e = Vegetacao
+			}
+			mapa[y] = append(mapa[y], e)
+		}
+	}
+	return mapa
+}
+
+// Confere se o caminho começa ao lado da origem, termina no destino, anda
+// uma célula por vez e só pisa em células passáveis
+func conferirCaminho(t *testing.T, mapa [][]Elemento, caminho []Ponto, origem, destino Ponto) {
+	t.Helper()
+	if len(caminho) == 0 || caminho[len(caminho)-1] != destino {
+		t.Fatalf("caminho %v não termina em %v", caminho, destino)
+	}
+	anterior := origem
+	for i, p := range caminho {
+		if abs(p.X-anterior.X)+abs(p.Y-anterior.Y) != 1 {
+			t.Fatalf("passo %d de %v para %v não é vizinho", i, anterior, p)
+		}
+		if p != destino && !passavelPadrao(p.X, p.Y, mapa[p.Y][p.X]) {
+			t.Fatalf("passo %d pisa em %v, que não é passável", i, p)
+		}
+		anterior = p
+	}
+}
+
+func TestBuscadorCaminho(t *testing.T) {
+	casos := []struct {
+		nome            string
+		mapa            []string
+		origem, destino Ponto
+		tamanho         int // 0 se não há caminho
+	}{
+		{"reto", []string{
+			"     ",
+		}, Ponto{0, 0}, Ponto{4, 0}, 4},
+		{"contorna a parede", []string{
+			"  #  ",
+			"  #  ",
+			"     ",
+		}, Ponto{0, 0}, Ponto{4, 0}, 8},
+		{"destino ocupado é aceito", []string{
+			"    #",
+		}, Ponto{0, 0}, Ponto{4, 0}, 4},
+		{"destino cercado", []string{
+			"   # ",
+			"   ##",
+		}, Ponto{0, 0}, Ponto{4, 0}, 0},
+		{"destino fora do mapa", []string{
+			"     ",
+		}, Ponto{0, 0}, Ponto{5, 0}, 0},
+		{"origem igual ao destino", []string{
+			"     ",
+		}, Ponto{2, 0}, Ponto{2, 0}, 0},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			mapa := mapaTeste(c.mapa...)
+			caminho := buscadorNovo(passavelPadrao).Caminho(mapa, c.origem, c.destino)
+			if c.tamanho == 0 {
+				if caminho != nil {
+					t.Fatalf("esperava nenhum caminho, veio %v", caminho)
+				}
+				return
+			}
+			if len(caminho) != c.tamanho {
+				t.Fatalf("caminho com %d passos, esperava %d: %v", len(caminho), c.tamanho, caminho)
+			}
+			conferirCaminho(t, mapa, caminho, c.origem, c.destino)
+		})
+	}
+}
+
+func TestBuscadorCacheInvalidado(t *testing.T) {
+	casos := []struct {
+		nome            string
+		mapa            []string
+		origem, destino Ponto
+		tamanho         int // passos do caminho depois do bloqueio; 0 se não há mais caminho
+	}{
+		{"desvia pela outra linha", []string{
+			"     ",
+			"     ",
+		}, Ponto{0, 0}, Ponto{4, 0}, 6},
+		{"passagem única fechada", []string{
+			"     ",
+			"## ##",
+			"     ",
+		}, Ponto{0, 0}, Ponto{4, 2}, 0},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			mapa := mapaTeste(c.mapa...)
+			b := buscadorNovo(passavelPadrao)
+			primeiro := b.Caminho(mapa, c.origem, c.destino)
+			conferirCaminho(t, mapa, primeiro, c.origem, c.destino)
+			if _, ok := b.cache[c.destino]; !ok {
+				t.Fatalf("o caminho para %v não foi guardado no cache", c.destino)
+			}
+
+			// Uma parede surge no meio do caminho guardado
+			bloqueio := primeiro[len(primeiro)/2]
+			mapa[bloqueio.Y][bloqueio.X] = Parede
+			novo := b.Caminho(mapa, c.origem, c.destino)
+			if c.tamanho == 0 {
+				if novo != nil {
+					t.Fatalf("esperava nenhum caminho depois de bloquear %v, veio %v", bloqueio, novo)
+				}
+				return
+			}
+			if len(novo) != c.tamanho {
+				t.Fatalf("caminho com %d passos, esperava %d: %v", len(novo), c.tamanho, novo)
+			}
+			conferirCaminho(t, mapa, novo, c.origem, c.destino)
+		})
+	}
+}
+
+func TestBuscadorCacheAoLongoDoCaminho(t *testing.T) {
+	mapa := mapaTeste(
+		"      ",
+		"      ",
+	)
+	b := buscadorNovo(passavelPadrao)
+	origem, destino := Ponto{0, 0}, Ponto{5, 1}
+	caminho := b.Caminho(mapa, origem, destino)
+	conferirCaminho(t, mapa, caminho, origem, destino)
+
+	// A partir de qualquer ponto do caminho, o resto vem do cache
+	for i, p := range caminho[:len(caminho)-1] {
+		resto := b.Caminho(mapa, p, destino)
+		if len(resto) != len(caminho)-i-1 || &resto[0] != &caminho[i+1] {
+			t.Fatalf("a partir de %v esperava o trecho guardado %v, veio %v", p, caminho[i+1:], resto)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// ciclo.go - Ciclo de vida da partida: goroutines em grupo, encerramento e relatório de falhas
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Tempo que o encerramento espera as goroutines da partida terminarem
+const limiteEncerramento = 2 * time.Second
+
+// Grupo reúne as goroutines de uma partida. Todas param quando o contexto do
+// grupo é cancelado, e o encerramento espera cada uma terminar. Um pânico em
+// qualquer delas é capturado e cancela as demais, em vez de derrubar o
+// programa com o terminal desconfigurado.
+type Grupo struct {
+	ctx       context.Context
+	cancelar  context.CancelFunc
+	acesso    chan bool      // exclusão mútua dos campos abaixo
+	ativas    map[int]string // goroutines em execução, pelo número de início
+	iniciadas int
+	mudou     chan bool // fechado e trocado sempre que uma goroutine termina
+	falha     *Falha    // primeiro pânico capturado
+}
+
+// Falha descreve o pânico que encerrou a partida
+type Falha struct {
+	Goroutine string    `json:"goroutine"`
+	Valor     string    `json:"valor"`
+	Pilha     string    `json:"pilha"`
+	Quando    time.Time `json:"quando"`
+	Pendentes []string  `json:"pendentes,omitempty"` // goroutines que não terminaram no encerramento
+	Estado    []string  `json:"-"`                   // estado da partida depois do encerramento
+}
+
+// Cria um grupo cujo contexto é cancelado junto com o contexto pai
+func grupoNovo(pai context.Context) *Grupo {
+	ctx, cancelar := context.WithCancel(pai)
+	g := &Grupo{ctx: ctx, cancelar: cancelar, acesso: make(chan bool, 1), ativas: map[int]string{}, mudou: make(chan bool)}
+	g.acesso <- true
+	return g
+}
+
+// Contexto cancelado quando a partida termina ou falha
+func (g *Grupo) Contexto() context.Context {
+	return g.ctx
+}
+
+// Inicia uma goroutine no grupo; o nome aparece nos relatórios
+func (g *Grupo) Iniciar(nome string, f func()) {
+	<-g.acesso
+	id := g.iniciadas
+	g.iniciadas++
+	g.ativas[id] = nome
+	g.acesso <- true
+
+	go func() {
+		defer g.terminar(id)
+		defer g.capturar(nome)
+		f()
+	}()
+}
+
+// Tira a goroutine da lista das ativas e avisa quem espera
+func (g *Grupo) terminar(id int) {
+	<-g.acesso
+	delete(g.ativas, id)
+	close(g.mudou)
+	g.mudou = make(chan bool)
+	g.acesso <- true
+}
+
+// Captura o pânico da goroutine indicada, guarda a falha e cancela o grupo.
+// Deve ser chamada com defer, diretamente.
+func (g *Grupo) capturar(nome string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	<-g.acesso
+	if g.falha == nil {
+		g.falha = &Falha{Goroutine: nome, Valor: fmt.Sprint(r), Pilha: string(debug.Stack()), Quando: time.Now()}
+	}
+	g.acesso <- true
+	g.cancelar()
+}
+
+// Pede a todas as goroutines do grupo que parem
+func (g *Grupo) Cancelar() {
+	g.cancelar()
+}
+
+// Espera as goroutines do grupo terminarem, até o limite. Retorna os nomes
+// das que ainda estavam em execução quando o limite passou.
+func (g *Grupo) Esperar(limite time.Duration) []string {
+	prazo := time.After(limite)
+	for {
+		<-g.acesso
+		if len(g.ativas) == 0 {
+			g.acesso <- true
+			return nil
+		}
+		mudou := g.mudou
+		var pendentes []string
+		for _, nome := range g.ativas {
+			pendentes = append(pendentes, nome)
+		}
+		g.acesso <- true
+
+		select {
+		case <-mudou:
+		case <-prazo:
+			slices.Sort(pendentes)
+			return pendentes
+		}
+	}
+}
+
+// Primeiro pânico capturado no grupo, ou nil
+func (g *Grupo) Falha() *Falha {
+	<-g.acesso
+	defer func() { g.acesso <- true }()
+	return g.falha
+}
+
+// Descreve o estado da partida para o relatório de falha. Só pode ser
+// chamada depois que todas as goroutines da partida terminaram.
+func jogoDespejo(jogo *Jogo) []string {
+	linhas := []string{
+		traduzir("falha.jogador", jogo.PosX, jogo.PosY, jogo.Vida, jogo.Pontos),
+		traduzir("falha.estatisticas", jogo.Stats),
+		traduzir("falha.semente", jogo.semente),
+	}
+	linhas = append(linhas, jogoDepuracao(jogo)...)
+
+	linhas = append(linhas, "", traduzir("falha.mensagens"))
+	todas := jogo.Mensagens.Todas()
+	for _, m := range todas[max(len(todas)-20, 0):] {
+		linhas = append(linhas, m.Formatar())
+	}
+
+	linhas = append(linhas, "", traduzir("falha.mapa"))
+	for y, linha := range jogo.Mapa {
+		runas := make([]rune, len(linha))
+		for x, elem := range linha {
+			runas[x] = elem.simbolo
+		}
+		if y == jogo.PosY && jogo.PosX >= 0 && jogo.PosX < len(runas) {
+			runas[jogo.PosX] = Personagem.simbolo
+		}
+		linhas = append(linhas, string(runas))
+	}
+	return linhas
+}
+
+// Grava o relatório da falha num arquivo novo no diretório atual e retorna
+// o caminho dele
+func falhaGravar(falha *Falha) (string, error) {
+	caminho := "falha-" + falha.Quando.Format("20060102-150405") + ".txt"
+	var b strings.Builder
+	fmt.Fprintln(&b, traduzir("falha.titulo", falha.Quando.Format(time.RFC3339)))
+	fmt.Fprintln(&b, traduzir("falha.goroutine", falha.Goroutine))
+	fmt.Fprintln(&b, traduzir("falha.panico", falha.Valor))
+	fmt.Fprintf(&b, "\n%s\n", falha.Pilha)
+	if len(falha.Pendentes) > 0 {
+		fmt.Fprintln(&b, traduzir("falha.pendentes", strings.Join(falha.Pendentes, ", ")))
+		fmt.Fprintln(&b, traduzir("falha.sem_estado"))
+	} else {
+		fmt.Fprintln(&b, traduzir("falha.estado"))
+		for _, linha := range falha.Estado {
+			b.WriteString(linha + "\n")
+		}
+	}
+	if err := os.WriteFile(caminho, []byte(b.String()), 0o644); err != nil {
+		return "", err
+	}
+	return caminho, nil
+}
@@ -0,0 +1,354 @@
+// config.go - Parâmetros de balanceamento lidos de um arquivo JSON
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Arquivo de configuração procurado quando nenhum é indicado
+const arquivoConfigPadrao = "config.json"
+
+// Duracao é um time.Duration escrito no JSON como texto ("800ms", "1.5s")
+type Duracao time.Duration
+
+func (d Duracao) Tempo() time.Duration { return time.Duration(d) }
+
+func (d Duracao) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duracao) UnmarshalJSON(dados []byte) error {
+	var texto string
+	if err := json.Unmarshal(dados, &texto); err != nil {
+		return erroTraduzido("erro.duracao", dados)
+	}
+	v, err := time.ParseDuration(texto)
+	if err != nil {
+		return err
+	}
+	*d = Duracao(v)
+	return nil
+}
+
+// Retangulo é uma área do mapa: X e Y são o canto superior esquerdo
+type Retangulo struct {
+	X       int `json:"x"`
+	Y       int `json:"y"`
+	Largura int `json:"largura"`
+	Altura  int `json:"altura"`
+}
+
+// Verifica se o ponto está dentro da área
+func (r Retangulo) Contem(p Ponto) bool {
+	return p.X >= r.X && p.X < r.X+r.Largura && p.Y >= r.Y && p.Y < r.Y+r.Altura
+}
+
+// Sorteia um ponto dentro da área
+func (r Retangulo) Sortear(rng *rand.Rand) Ponto {
+	return Ponto{r.X + rng.Intn(r.Largura), r.Y + rng.Intn(r.Altura)}
+}
+
+// Configuracao reúne todos os parâmetros ajustáveis dos elementos
+type Configuracao struct {
+	Jogador struct {
+		Vidas              int     `json:"vidas"`
+		IntervaloCaminhada Duracao `json:"intervalo_caminhada"` // tempo entre passos ao andar até onde se clicou
+		Armadilhas         int     `json:"armadilhas"`          // armadilhas no inventário ao começar
+		Iscas              int     `json:"iscas"`               // iscas no inventário ao começar
+	} `json:"jogador"`
+
+	Patrulha struct {
+		Intervalo Duracao `json:"intervalo"` // tempo entre dois passos (também o padrão das rotas)
+		Inicio    Ponto   `json:"inicio"`    // posição do inimigo de patrulha em mapas sem rotas
+	} `json:"patrulha"`
+
+	Portal struct {
+		Intervalo Duracao `json:"intervalo"` // tempo entre tentativas de abrir um portal
+		Aberto    Duracao `json:"aberto"`    // quanto tempo o portal fica aberto
+		AutoUso   Duracao `json:"auto_uso"`  // tempo parado sobre o portal até usá-lo sozinho
+	} `json:"portal"`
+
+	Fantasma struct {
+		Intervalo  Duracao `json:"intervalo"`
+		Toca       Ponto   `json:"toca"` // para onde volta depois de pegar o jogador
+		Penalidade int     `json:"penalidade"`
+	} `json:"fantasma"`
+
+	Armadilha struct {
+		Chance     int     `json:"chance"` // 1 em chance a cada pulso do controle central
+		Raio       int     `json:"raio"`   // distância máxima do jogador onde surge
+		Expiracao  Duracao `json:"expiracao"`
+		Penalidade int     `json:"penalidade"` // pontos perdidos nos espinhos
+		Oculta     int     `json:"oculta"`     // 1 em oculta surge escondida
+		Busca      int     `json:"busca"`      // distância até onde o jogador procura as escondidas
+		Laco       Duracao `json:"laco"`       // tempo que o laço prende o jogador
+
+		// Tempo que quem pisa numa armadilha do jogador fica atordoado
+		Atordoamento Duracao `json:"atordoamento"`
+
+		// Tempo até cada tipo voltar a funcionar depois de disparar
+		Rearme struct {
+			Espinhos  Duracao `json:"espinhos"`
+			Laco      Duracao `json:"laco"`
+			Alarme    Duracao `json:"alarme"`
+			Teleporte Duracao `json:"teleporte"`
+		} `json:"rearme"`
+	} `json:"armadilha"`
+
+	Isca struct {
+		Duracao Duracao `json:"duracao"` // tempo até a isca sumir
+		Raio    int     `json:"raio"`    // distância de onde os inimigos de rota a percebem
+	} `json:"isca"`
+
+	Tesouro struct {
+		Chance int `json:"chance"` // 1 em chance a cada pulso do controle central
+		Pontos int `json:"pontos"`
+	} `json:"tesouro"`
+
+	Guardiao struct {
+		Intervalo         Duracao `json:"intervalo"`
+		Posto             Ponto   `json:"posto"`
+		RaioVisao         int     `json:"raio_visao"`         // distância em que percebe o jogador
+		RaioPerseguicao   int     `json:"raio_perseguicao"`   // distância em que continua enxergando quem já persegue
+		TempoDesconfianca Duracao `json:"tempo_desconfianca"` // quanto tempo investiga antes de desistir
+		TempoPerseguicao  Duracao `json:"tempo_perseguicao"`  // desiste se não alcançar o jogador neste tempo
+		IntervaloAtaque   Duracao `json:"intervalo_ataque"`   // tempo entre dois golpes
+		Penalidade        int     `json:"penalidade"`
+	} `json:"guardiao"`
+
+	Enxame struct {
+		Intervalo  Duracao `json:"intervalo"`
+		Dispersao  int     `json:"dispersao"` // pulsos fugindo depois de alcançar o jogador
+		Penalidade int     `json:"penalidade"`
+	} `json:"enxame"`
+
+	Controle struct {
+		Intervalo      Duracao   `json:"intervalo"`
+		PerseguirAposX int       `json:"perseguir_apos_x"` // o fantasma persegue quem passa desta coluna
+		AreaGuardiao   Retangulo `json:"area_guardiao"`    // área vigiada pelo guardião
+		PulsosAlarme   int       `json:"pulsos_alarme"`    // pulsos seguidos na área até disparar o alarme
+		AreaSurgimento Retangulo `json:"area_surgimento"`  // onde surgem portais e tesouros e aonde os portais levam
+	} `json:"controle"`
+
+	Mensagens struct {
+		Linhas      int     `json:"linhas"`       // mensagens mostradas na barra de status
+		TempoMinimo Duracao `json:"tempo_minimo"` // tempo em que as mensagens de perigo não saem da barra
+		Limite      int     `json:"limite"`       // mensagens guardadas no histórico
+	} `json:"mensagens"`
+
+	Relogio struct {
+		Passo Duracao `json:"passo"` // passo fixo da simulação em tempo real
+	} `json:"relogio"`
+
+	Aparencia struct {
+		Tema   string `json:"tema"`   // tema pronto ou arquivo de tema
+		Cores  string `json:"cores"`  // auto, basicas, 256 ou rgb
+		Glifos string `json:"glifos"` // auto, unicode, ascii ou arquivo de glifos
+
+		Minimapa bool `json:"minimapa"` // exibe o minimapa desde o início
+		Quadros  int  `json:"quadros"`  // quadros desenhados por segundo, no máximo
+	} `json:"aparencia"`
+
+	Teclas ConfigTeclas `json:"teclas"`
+}
+
+// Valores usados quando o arquivo não define um parâmetro
+func configPadrao() *Configuracao {
+	c := &Configuracao{}
+	c.Jogador.Vidas = 3
+	c.Jogador.IntervaloCaminhada = Duracao(150 * time.Millisecond)
+	c.Jogador.Armadilhas = 2
+	c.Jogador.Iscas = 2
+
+	c.Patrulha.Intervalo = Duracao(800 * time.Millisecond)
+	c.Patrulha.Inicio = Ponto{10, 5}
+
+	c.Portal.Intervalo = Duracao(10 * time.Second)
+	c.Portal.Aberto = Duracao(7 * time.Second)
+	c.Portal.AutoUso = Duracao(1 * time.Second)
+
+	c.Fantasma.Intervalo = Duracao(1 * time.Second)
+	c.Fantasma.Toca = Ponto{15, 15}
+	c.Fantasma.Penalidade = 5
+
+	c.Armadilha.Chance = 25
+	c.Armadilha.Raio = 2
+	c.Armadilha.Expiracao = Duracao(6 * time.Second)
+	c.Armadilha.Penalidade = 3
+	c.Armadilha.Oculta = 4
+	c.Armadilha.Busca = 1
+	c.Armadilha.Laco = Duracao(1500 * time.Millisecond)
+	c.Armadilha.Atordoamento = Duracao(3 * time.Second)
+	c.Armadilha.Rearme.Espinhos = Duracao(2 * time.Second)
+	c.Armadilha.Rearme.Laco = Duracao(3 * time.Second)
+	c.Armadilha.Rearme.Alarme = Duracao(5 * time.Second)
+	c.Armadilha.Rearme.Teleporte = Duracao(4 * time.Second)
+
+	c.Isca.Duracao = Duracao(8 * time.Second)
+	c.Isca.Raio = 8
+
+	c.Tesouro.Chance = 20
+	c.Tesouro.Pontos = 10
+
+	c.Guardiao.Intervalo = Duracao(500 * time.Millisecond)
+	c.Guardiao.Posto = Ponto{25, 10}
+	c.Guardiao.RaioVisao = 3
+	c.Guardiao.RaioPerseguicao = 6
+	c.Guardiao.TempoDesconfianca = Duracao(6 * time.Second)
+	c.Guardiao.TempoPerseguicao = Duracao(15 * time.Second)
+	c.Guardiao.IntervaloAtaque = Duracao(1 * time.Second)
+	c.Guardiao.Penalidade = 4
+
+	c.Enxame.Intervalo = Duracao(600 * time.Millisecond)
+	c.Enxame.Dispersao = 5
+	c.Enxame.Penalidade = 2
+
+	c.Controle.Intervalo = Duracao(3 * time.Second)
+	c.Controle.PerseguirAposX = 30
+	c.Controle.AreaGuardiao = Retangulo{X: 21, Y: 0, Largura: 58, Altura: 15}
+	c.Controle.PulsosAlarme = 2
+	c.Controle.AreaSurgimento = Retangulo{X: 5, Y: 5, Largura: 70, Altura: 20}
+
+	c.Mensagens.Linhas = 3
+	c.Mensagens.TempoMinimo = Duracao(3 * time.Second)
+	c.Mensagens.Limite = 500
+
+	c.Relogio.Passo = Duracao(10 * time.Millisecond)
+
+	c.Aparencia.Tema = "padrao"
+	c.Aparencia.Cores = "auto"
+	c.Aparencia.Glifos = "auto"
+	c.Aparencia.Minimapa = true
+	c.Aparencia.Quadros = 30
+
+	c.Teclas.Layout = "qwerty"
+	return c
+}
+
+// Formato do arquivo: os parâmetros gerais e, em "mapas", ajustes que valem
+// só para um mapa, indexados pelo nome do arquivo do mapa
+type arquivoConfiguracao struct {
+	*Configuracao
+	Mapas map[string]json.RawMessage `json:"mapas"`
+}
+
+// Carrega a configuração para o mapa indicado. Parte dos valores padrão,
+// aplica o arquivo e depois os ajustes do mapa. Sem arquivo indicado, usa
+// config.json se ele existir.
+func configCarregar(arquivo, mapa string) (*Configuracao, error) {
+	c := configPadrao()
+	if arquivo == "" {
+		if _, err := os.Stat(arquivoConfigPadrao); err != nil {
+			return c, nil
+		}
+		arquivo = arquivoConfigPadrao
+	}
+
+	dados, err := os.ReadFile(arquivo)
+	if err != nil {
+		return nil, err
+	}
+	arq := arquivoConfiguracao{Configuracao: c}
+	if err := configDecodificar(dados, &arq); err != nil {
+		return nil, fmt.Errorf("%s: %v", arquivo, err)
+	}
+	if ajustes, ok := arq.Mapas[filepath.Base(mapa)]; ok {
+		if err := configDecodificar(ajustes, c); err != nil {
+			return nil, fmt.Errorf("%s: mapas.%s: %v", arquivo, filepath.Base(mapa), err)
+		}
+	}
+	if err := configValidar(c); err != nil {
+		return nil, fmt.Errorf("%s: %v", arquivo, err)
+	}
+	return c, nil
+}
+
+// Decodifica o JSON sobre os valores já preenchidos, recusando chaves
+// desconhecidas para que erros de digitação não passem despercebidos
+func configDecodificar(dados []byte, destino any) error {
+	dec := json.NewDecoder(bytes.NewReader(dados))
+	dec.DisallowUnknownFields()
+	return dec.Decode(destino)
+}
+
+// Confere se os valores fazem sentido, relatando todos os problemas de uma vez
+func configValidar(c *Configuracao) error {
+	var erros []error
+	positiva := func(nome string, d Duracao) {
+		if d <= 0 {
+			erros = append(erros, erroTraduzido("erro.positivo", nome))
+		}
+	}
+	minimo := func(nome string, v, min int) {
+		if v < min {
+			erros = append(erros, erroTraduzido("erro.minimo", nome, min))
+		}
+	}
+
+	minimo("jogador.vidas", c.Jogador.Vidas, 1)
+	positiva("jogador.intervalo_caminhada", c.Jogador.IntervaloCaminhada)
+	minimo("jogador.armadilhas", c.Jogador.Armadilhas, 0)
+	minimo("jogador.iscas", c.Jogador.Iscas, 0)
+	positiva("patrulha.intervalo", c.Patrulha.Intervalo)
+	positiva("portal.intervalo", c.Portal.Intervalo)
+	positiva("portal.aberto", c.Portal.Aberto)
+	positiva("portal.auto_uso", c.Portal.AutoUso)
+	positiva("fantasma.intervalo", c.Fantasma.Intervalo)
+	minimo("fantasma.penalidade", c.Fantasma.Penalidade, 0)
+	minimo("armadilha.chance", c.Armadilha.Chance, 1)
+	minimo("armadilha.raio", c.Armadilha.Raio, 0)
+	positiva("armadilha.expiracao", c.Armadilha.Expiracao)
+	minimo("armadilha.penalidade", c.Armadilha.Penalidade, 0)
+	minimo("armadilha.oculta", c.Armadilha.Oculta, 1)
+	minimo("armadilha.busca", c.Armadilha.Busca, 0)
+	positiva("armadilha.laco", c.Armadilha.Laco)
+	positiva("armadilha.atordoamento", c.Armadilha.Atordoamento)
+	positiva("armadilha.rearme.espinhos", c.Armadilha.Rearme.Espinhos)
+	positiva("armadilha.rearme.laco", c.Armadilha.Rearme.Laco)
+	positiva("armadilha.rearme.alarme", c.Armadilha.Rearme.Alarme)
+	positiva("armadilha.rearme.teleporte", c.Armadilha.Rearme.Teleporte)
+	positiva("isca.duracao", c.Isca.Duracao)
+	minimo("isca.raio", c.Isca.Raio, 0)
+	minimo("tesouro.chance", c.Tesouro.Chance, 1)
+	minimo("tesouro.pontos", c.Tesouro.Pontos, 0)
+	positiva("guardiao.intervalo", c.Guardiao.Intervalo)
+	minimo("guardiao.raio_visao", c.Guardiao.RaioVisao, 0)
+	minimo("guardiao.raio_perseguicao", c.Guardiao.RaioPerseguicao, c.Guardiao.RaioVisao)
+	positiva("guardiao.tempo_desconfianca", c.Guardiao.TempoDesconfianca)
+	positiva("guardiao.tempo_perseguicao", c.Guardiao.TempoPerseguicao)
+	positiva("guardiao.intervalo_ataque", c.Guardiao.IntervaloAtaque)
+	minimo("guardiao.penalidade", c.Guardiao.Penalidade, 0)
+	positiva("enxame.intervalo", c.Enxame.Intervalo)
+	minimo("enxame.dispersao", c.Enxame.Dispersao, 0)
+	minimo("enxame.penalidade", c.Enxame.Penalidade, 0)
+	positiva("controle.intervalo", c.Controle.Intervalo)
+	minimo("controle.pulsos_alarme", c.Controle.PulsosAlarme, 1)
+	minimo("controle.area_guardiao.largura", c.Controle.AreaGuardiao.Largura, 1)
+	minimo("controle.area_guardiao.altura", c.Controle.AreaGuardiao.Altura, 1)
+	minimo("controle.area_surgimento.largura", c.Controle.AreaSurgimento.Largura, 1)
+	minimo("controle.area_surgimento.altura", c.Controle.AreaSurgimento.Altura, 1)
+	minimo("mensagens.linhas", c.Mensagens.Linhas, 1)
+	positiva("mensagens.tempo_minimo", c.Mensagens.TempoMinimo)
+	minimo("mensagens.limite", c.Mensagens.Limite, c.Mensagens.Linhas)
+	positiva("relogio.passo", c.Relogio.Passo)
+	minimo("aparencia.quadros", c.Aparencia.Quadros, 1)
+	if modo, err := modoCoresEscolher(c.Aparencia.Cores); err != nil {
+		erros = append(erros, fmt.Errorf("aparencia.cores: %v", err))
+	} else if _, err := temaCarregar(c.Aparencia.Tema, modo); err != nil {
+		erros = append(erros, fmt.Errorf("aparencia.tema: %v", err))
+	}
+	if _, err := glifosCarregar(c.Aparencia.Glifos); err != nil {
+		erros = append(erros, fmt.Errorf("aparencia.glifos: %v", err))
+	}
+	if _, err := teclasMontar(c.Teclas); err != nil {
+		erros = append(erros, fmt.Errorf("teclas: %v", err))
+	}
+	return errors.Join(erros...)
+}
@@ -0,0 +1,128 @@
+// depuracao.go - Painel de depuração: estado das goroutines, filas de eventos e espera pelo dono do jogo
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// Posição informada pelos elementos que não ocupam uma célula
+var semPosicao = Ponto{-1, -1}
+
+// EstadoElemento é o que a goroutine de um elemento informou por último
+type EstadoElemento struct {
+	Estado string        // texto já traduzido
+	Pos    Ponto         // semPosicao se o elemento não ocupa uma célula
+	Quando time.Duration // tempo da partida em que o estado foi informado
+}
+
+// EsperaIntencoes resume quanto as goroutines esperaram o dono do jogo
+// aplicar as suas intenções. Só as disputadas, enviadas enquanto o dono
+// aplicava outra, são cronometradas.
+type EsperaIntencoes struct {
+	Aplicadas  int
+	Disputadas int
+	Total      time.Duration // soma das esperas disputadas
+	Maxima     time.Duration
+	Ultima     time.Duration
+}
+
+// Contador compartilhado entre goroutines: o canal guarda o valor e serve
+// de trava
+type Contador chan int
+
+func contadorNovo() Contador {
+	c := make(Contador, 1)
+	c <- 0
+	return c
+}
+
+func (c Contador) Incrementar() {
+	c <- <-c + 1
+}
+
+func (c Contador) Definir(v int) {
+	<-c
+	c <- v
+}
+
+func (c Contador) Valor() int {
+	v := <-c
+	c <- v
+	return v
+}
+
+// Pedidos de desenho perdidos porque o canal do desenho estava cheio
+var desenhosDescartados = contadorNovo()
+
+// Registra o estado atual de um elemento para o painel. O estado é um texto
+// do catálogo do idioma, como nas mensagens. (chamada pelo dono do jogo)
+func jogoInformarEstado(jogo *Jogo, nome string, pos Ponto, id string, valores ...any) {
+	if jogo.estados == nil {
+		jogo.estados = make(map[string]EstadoElemento)
+	}
+	estado := traduzir(id, valores...)
+	anterior, existia := jogo.estados[nome]
+	jogo.estados[nome] = EstadoElemento{Estado: estado, Pos: pos, Quando: jogo.relogio.Agora()}
+
+	// O diário guarda só as mudanças de estado, não cada passo
+	if !existia || anterior.Estado != estado {
+		jogo.diario.Registrar("estado", diarioEstado{Elemento: nome, ID: id, Estado: estado, Pos: pos})
+	}
+}
+
+// Abre ou fecha o painel de depuração (chamada pelo dono do jogo)
+func jogoAlternarDepuracao(jogo *Jogo) {
+	jogo.depuracaoVisivel = !jogo.depuracaoVisivel
+	if jogo.depuracaoVisivel {
+		jogoMensagem(jogo, GravidadeInfo, "jogador", "depuracao.exibida")
+	} else {
+		jogoMensagem(jogo, GravidadeInfo, "jogador", "depuracao.oculta")
+	}
+}
+
+// Linhas do painel de depuração (chamada pelo dono do jogo)
+func jogoDepuracao(jogo *Jogo) []string {
+	linhas := []string{traduzir("depuracao.titulo", runtime.NumGoroutine(), jogo.relogio.Agora().Round(time.Millisecond))}
+
+	// Estado de cada elemento, em ordem de nome
+	nomes := make([]string, 0, len(jogo.estados))
+	for nome := range jogo.estados {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	for _, nome := range nomes {
+		e := jogo.estados[nome]
+		linha := fmt.Sprintf(" %s: %s", nome, e.Estado)
+		if e.Pos != semPosicao {
+			linha += traduzir("depuracao.posicao", e.Pos.X, e.Pos.Y)
+		}
+		linhas = append(linhas, linha)
+	}
+
+	// Filas do barramento, que ligam os elementos, e o canal do desenho
+	linhas = append(linhas, traduzir("depuracao.filas"))
+	for _, r := range jogo.eventos.Relatorio() {
+		capacidade := "∞"
+		if !r.Garantida {
+			capacidade = fmt.Sprint(r.Capacidade)
+		}
+		linhas = append(linhas, traduzir("depuracao.fila", r.Nome, r.Pendentes, capacidade, r.Entregues, r.Descartados))
+	}
+	linhas = append(linhas, traduzir("depuracao.fila", "canalDesenho", len(canalDesenho), fmt.Sprint(cap(canalDesenho)), "-", desenhosDescartados.Valor()))
+	linhas = append(linhas, traduzir("depuracao.quadros", quadrosDesenhados.Valor(), pedidosAgrupados.Valor(), celulasRedesenhadas.Valor()))
+	passo, passos, descartados := jogo.relogio.Passos()
+	linhas = append(linhas, traduzir("depuracao.relogio", passo, passos, descartados))
+
+	// Espera pelo dono do jogo
+	e := jogo.espera
+	media := time.Duration(0)
+	if e.Disputadas > 0 {
+		media = e.Total / time.Duration(e.Disputadas)
+	}
+	linhas = append(linhas, traduzir("depuracao.acesso", e.Aplicadas, e.Disputadas),
+		traduzir("depuracao.espera", media.Round(time.Microsecond), e.Ultima.Round(time.Microsecond), e.Maxima.Round(time.Microsecond)))
+	return linhas
+}
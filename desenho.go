@@ -0,0 +1,150 @@
+// desenho.go - Quadros da tela: pedidos de desenho agrupados e só as células que mudaram redesenhadas
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Intervalo mínimo entre dois quadros; trocado por interfaceDefinirQuadros
+var intervaloQuadro = time.Second / 30
+
+// Define quantos quadros por segundo, no máximo, são desenhados
+func interfaceDefinirQuadros(porSegundo int) {
+	intervaloQuadro = time.Second / time.Duration(porSegundo)
+}
+
+// Pedido de quadro pendente. Os pedidos que chegam antes do próximo quadro
+// ser desenhado entram nele, em vez de virar um quadro cada, e são contados.
+var pedidoQuadro = make(chan *Jogo, 1)
+
+// Contadores do desenho para o painel de depuração
+var (
+	quadrosDesenhados   = contadorNovo()
+	pedidosAgrupados    = contadorNovo() // pedidos atendidos por um quadro já pedido
+	celulasRedesenhadas = contadorNovo() // células do mapa desenhadas no último quadro
+)
+
+// Quadro guarda o que foi desenhado no último quadro, para que o próximo só
+// redesenhe as células que mudaram. Só o worker de desenho o usa.
+type Quadro struct {
+	layout  LayoutQuadro
+	mapa    [][]Elemento // mapa do instantâneo desenhado
+	jogador Ponto
+}
+
+// LayoutQuadro é o que, se mudar, obriga a redesenhar a tela inteira
+type LayoutQuadro struct {
+	largura, altura int
+	linhas          int // linhas do mapa, que mudam com um mapa novo
+	janela          Janela
+	minimapa        bool // o minimapa cobre parte do mapa
+	painel          bool // o painel de depuração cobre parte do mapa
+}
+
+// Último quadro desenhado; nil força o próximo a redesenhar tudo
+var quadroAnterior *Quadro
+
+// Inicia o laço de desenho: um quadro por intervalo, independente dos
+// passos da simulação, até o contexto ser cancelado. Os elementos não pedem
+// desenhos; um quadro sem mudanças custa só a comparação das linhas.
+func interfaceIniciarQuadros(ctx context.Context, jogo *Jogo) {
+	jogo.grupo.Iniciar("quadros", func() {
+		ticker := time.NewTicker(intervaloQuadro)
+		defer ticker.Stop()
+		interfacePedirQuadro(jogo)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				interfacePedirQuadro(jogo)
+			}
+		}
+	})
+}
+
+// Pede um quadro ao worker de desenho sem esperar por ele
+func interfacePedirQuadro(jogo *Jogo) {
+	select {
+	case pedidoQuadro <- jogo:
+	default:
+		// O worker ainda não desenhou o quadro anterior: este entra nele
+		pedidosAgrupados.Incrementar()
+	}
+}
+
+// Desenha um quadro respeitando o intervalo mínimo desde o anterior e
+// retorna a hora em que terminou (executada pelo worker)
+func desenharQuadro(jogo *Jogo, ultimo time.Time) time.Time {
+	if espera := intervaloQuadro - time.Since(ultimo); espera > 0 {
+		time.Sleep(espera)
+	}
+	// Um pedido feito durante a espera é atendido por este mesmo quadro
+	select {
+	case <-pedidoQuadro:
+		pedidosAgrupados.Incrementar()
+	default:
+	}
+	renderizarJogoSeguro(jogo)
+	quadrosDesenhados.Incrementar()
+	return time.Now()
+}
+
+// Desenha o trecho visível do mapa e o personagem. Com o quadro anterior no
+// mesmo layout, só as células diferentes são desenhadas: as linhas que o
+// instantâneo reaproveitou nem são comparadas.
+func desenharMapa(inst *Instantaneo, janela Janela, anterior *Quadro) {
+	mapa := inst.Mapa
+	celulas := 0
+	for y := janela.Y; y < janela.Y+janela.Altura; y++ {
+		linha := mapa[y]
+		var antiga []Elemento
+		if anterior != nil {
+			antiga = anterior.mapa[y]
+			if mesmaLinha(linha, antiga) {
+				continue
+			}
+		}
+		for x := janela.X; x < janela.X+janela.Largura && x < len(linha); x++ {
+			if anterior == nil || x >= len(antiga) || linha[x] != antiga[x] {
+				desenharCelula((x-janela.X)*janela.Celula, y-janela.Y, linha[x])
+				celulas++
+			}
+		}
+	}
+
+	// O personagem saiu de onde estava: a célula volta a mostrar o mapa
+	pos := inst.Jogador
+	if anterior != nil && anterior.jogador != pos && dentroDaJanela(janela, anterior.jogador) {
+		p := anterior.jogador
+		desenharCelula((p.X-janela.X)*janela.Celula, p.Y-janela.Y, mapa[p.Y][p.X])
+		celulas++
+	}
+	// Desenha o personagem sobre o mapa (se estiver no trecho visível)
+	if dentroDaJanela(janela, pos) {
+		desenharCelula((pos.X-janela.X)*janela.Celula, pos.Y-janela.Y, Personagem)
+	}
+	celulasRedesenhadas.Definir(celulas)
+}
+
+// Indica se as duas linhas são a mesma fatia, reaproveitada entre instantâneos
+func mesmaLinha(a, b []Elemento) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// Indica se a célula do mapa está no trecho visível
+func dentroDaJanela(janela Janela, p Ponto) bool {
+	return p.X >= janela.X && p.X < janela.X+janela.Largura && p.Y >= janela.Y && p.Y < janela.Y+janela.Altura
+}
+
+// Apaga as linhas da tela a partir de y, onde fica a barra de status
+func limparLinhas(y, largura, altura int) {
+	for ; y < altura; y++ {
+		for x := 0; x < largura; x++ {
+			termbox.SetCell(x, y, ' ', CorPadrao, CorPadrao)
+		}
+	}
+}
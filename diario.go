@@ -0,0 +1,206 @@
+// diario.go - Diário da sessão: os acontecimentos da partida gravados em JSON, um por linha
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Diario grava cada acontecimento da partida como uma linha JSON. Quando o
+// arquivo passa do tamanho máximo ele é girado: o atual vira caminho.1, o
+// caminho.1 vira caminho.2 e assim por diante, até o número de cópias. Um
+// Diario nil não grava nada, então os elementos podem registrar sem conferir.
+type Diario struct {
+	acesso  chan bool // exclusão mútua do arquivo e do contador de ticks
+	caminho string
+	arquivo *os.File
+	tamanho int64 // bytes já escritos no arquivo atual
+	limite  int64 // tamanho a partir do qual o arquivo é girado
+	copias  int   // arquivos girados que são mantidos
+	tick    uint64
+	agora   func() time.Duration // tempo da partida, dado pelo relógio do jogo
+	erro    error                // primeiro erro de escrita; depois dele nada é gravado
+}
+
+// EntradaDiario é uma linha do diário
+type EntradaDiario struct {
+	Tick  uint64    `json:"tick"`     // sequência das entradas, sem saltos nem repetições
+	Hora  time.Time `json:"hora"`     // horário do relógio do sistema
+	Tempo int64     `json:"tempo_ms"` // tempo da partida, que não corre com o jogo pausado
+	Tipo  string    `json:"tipo"`
+	Dados any       `json:"dados,omitempty"`
+}
+
+// Entrega de um evento publicado no barramento
+type diarioEvento struct {
+	Evento     TipoEvento `json:"evento"`
+	Campos     Evento     `json:"campos"`
+	Entregue   []string   `json:"entregue,omitempty"`   // inscritos que receberam o evento
+	Descartado []string   `json:"descartado,omitempty"` // inscritos com a fila cheia
+}
+
+// Mensagem mostrada ao jogador
+type diarioMensagem struct {
+	Gravidade string `json:"gravidade"`
+	Origem    string `json:"origem"`
+	ID        string `json:"id"`
+	Texto     string `json:"texto"`
+}
+
+// Mudança no estado informado por um elemento ao painel de depuração
+type diarioEstado struct {
+	Elemento string `json:"elemento"`
+	ID       string `json:"id"`     // identificador do texto no catálogo do idioma
+	Estado   string `json:"estado"` // texto já traduzido
+	Pos      Ponto  `json:"pos"`    // (-1, -1) se o elemento não ocupa uma célula
+}
+
+// Início e fim da partida
+type diarioPartida struct {
+	Mapa    string        `json:"mapa,omitempty"`
+	Semente int64         `json:"semente,omitempty"`
+	Jogador Ponto         `json:"jogador"`
+	Vida    int           `json:"vida"`
+	Pontos  int           `json:"pontos"`
+	Stats   *Estatisticas `json:"stats,omitempty"`
+}
+
+// Abre o diário no caminho indicado. Um arquivo de uma sessão anterior é
+// girado antes, para que cada sessão comece num arquivo próprio.
+func diarioAbrir(caminho string, limite int64, copias int) (*Diario, error) {
+	if limite <= 0 {
+		return nil, erroTraduzido("erro.diario_tamanho")
+	}
+	if copias < 0 {
+		return nil, erroTraduzido("erro.diario_copias")
+	}
+	d := &Diario{acesso: make(chan bool, 1), caminho: caminho, limite: limite, copias: copias}
+	if info, err := os.Stat(caminho); err == nil && info.Size() > 0 {
+		if err := d.girar(); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.abrirArquivo(); err != nil {
+		return nil, err
+	}
+	d.acesso <- true
+	return d, nil
+}
+
+// Cria o arquivo atual, vazio
+func (d *Diario) abrirArquivo() error {
+	arquivo, err := os.OpenFile(d.caminho, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return erroTraduzido("erro.diario", err)
+	}
+	d.arquivo, d.tamanho = arquivo, 0
+	return nil
+}
+
+// Desloca os arquivos girados e move o atual para caminho.1; sem cópias o
+// atual é apagado
+func (d *Diario) girar() error {
+	if d.arquivo != nil {
+		d.arquivo.Close()
+		d.arquivo = nil
+	}
+	if d.copias == 0 {
+		if err := os.Remove(d.caminho); err != nil && !os.IsNotExist(err) {
+			return erroTraduzido("erro.diario", err)
+		}
+		return nil
+	}
+	for i := d.copias - 1; i >= 1; i-- {
+		origem := fmt.Sprintf("%s.%d", d.caminho, i)
+		if err := os.Rename(origem, fmt.Sprintf("%s.%d", d.caminho, i+1)); err != nil && !os.IsNotExist(err) {
+			return erroTraduzido("erro.diario", err)
+		}
+	}
+	if err := os.Rename(d.caminho, d.caminho+".1"); err != nil && !os.IsNotExist(err) {
+		return erroTraduzido("erro.diario", err)
+	}
+	return nil
+}
+
+// Passa a usar o relógio dado para o tempo da partida
+func (d *Diario) Relogio(agora func() time.Duration) {
+	if d == nil {
+		return
+	}
+	<-d.acesso
+	d.agora = agora
+	d.acesso <- true
+}
+
+// Grava uma entrada. Pode ser chamada de qualquer goroutine, inclusive do
+// dono do jogo; erros de escrita são guardados e devolvidos por Fechar.
+func (d *Diario) Registrar(tipo string, dados any) {
+	if d == nil {
+		return
+	}
+	<-d.acesso
+	defer func() { d.acesso <- true }()
+	if d.arquivo == nil || d.erro != nil {
+		return
+	}
+
+	d.tick++
+	entrada := EntradaDiario{Tick: d.tick, Hora: time.Now(), Tipo: tipo, Dados: dados}
+	if d.agora != nil {
+		entrada.Tempo = d.agora().Milliseconds()
+	}
+	linha, err := json.Marshal(entrada)
+	if err != nil {
+		d.erro = erroTraduzido("erro.diario", err)
+		return
+	}
+	linha = append(linha, '\n')
+
+	if d.tamanho > 0 && d.tamanho+int64(len(linha)) > d.limite {
+		if err := d.girar(); err != nil {
+			d.erro = err
+			return
+		}
+		if d.erro = d.abrirArquivo(); d.erro != nil {
+			return
+		}
+	}
+	n, err := d.arquivo.Write(linha)
+	d.tamanho += int64(n)
+	if err != nil {
+		d.erro = erroTraduzido("erro.diario", err)
+	}
+}
+
+// Fecha o arquivo; as entradas registradas depois disso são ignoradas.
+// Retorna o primeiro erro de escrita, se houve algum.
+func (d *Diario) Fechar() error {
+	if d == nil {
+		return nil
+	}
+	<-d.acesso
+	defer func() { d.acesso <- true }()
+	if d.arquivo != nil {
+		if err := d.arquivo.Close(); err != nil && d.erro == nil {
+			d.erro = erroTraduzido("erro.diario", err)
+		}
+		d.arquivo = nil
+	}
+	return d.erro
+}
+
+// Liga o diário ao jogo: o tempo vem do relógio da partida e todo evento
+// publicado no barramento é gravado com os inscritos que o receberam ou
+// perderam
+func jogoLigarDiario(jogo *Jogo, diario *Diario) {
+	if diario == nil {
+		return
+	}
+	jogo.diario = diario
+	diario.Relogio(jogo.relogio.Agora)
+	jogo.eventos.Observar(func(ev Evento, entregue, descartado []string) {
+		diario.Registrar("evento", diarioEvento{Evento: ev.Tipo(), Campos: ev, Entregue: entregue, Descartado: descartado})
+	})
+}
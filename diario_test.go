@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Lê os ticks gravados num arquivo do diário; nil se o arquivo não existe
+func ticksDiario(t *testing.T, caminho string) []uint64 {
+	t.Helper()
+	arquivo, err := os.Open(caminho)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer arquivo.Close()
+
+	var ticks []uint64
+	linhas := bufio.NewScanner(arquivo)
+	for linhas.Scan() {
+		var entrada EntradaDiario
+		if err := json.Unmarshal(linhas.Bytes(), &entrada); err != nil {
+			t.Fatalf("%s: linha inválida %q: %v", caminho, linhas.Text(), err)
+		}
+		ticks = append(ticks, entrada.Tick)
+	}
+	return ticks
+}
+
+func TestDiarioGirar(t *testing.T) {
+	const (
+		limite   = 300
+		entradas = 40
+	)
+	casos := []struct {
+		nome   string
+		copias int
+	}{
+		{"sem cópias", 0},
+		{"uma cópia", 1},
+		{"três cópias", 3},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			caminho := filepath.Join(t.TempDir(), "partida.log")
+			d, err := diarioAbrir(caminho, limite, c.copias)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range entradas {
+				d.Registrar("teste", diarioMensagem{Origem: "teste", ID: fmt.Sprint(i)})
+			}
+			if err := d.Fechar(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Do arquivo mais antigo ao atual, os ticks seguem sem saltos e
+			// terminam na última entrada
+			var ticks []uint64
+			for i := c.copias; i >= 0; i-- {
+				nome := caminho
+				if i > 0 {
+					nome = fmt.Sprintf("%s.%d", caminho, i)
+				}
+				if info, err := os.Stat(nome); err != nil {
+					t.Fatalf("%s: %v", nome, err)
+				} else if info.Size() > limite {
+					t.Fatalf("%s tem %d bytes, mais que o limite de %d", nome, info.Size(), limite)
+				}
+				ticks = append(ticks, ticksDiario(t, nome)...)
+			}
+			for i, tick := range ticks {
+				if want := uint64(entradas - len(ticks) + i + 1); tick != want {
+					t.Fatalf("tick %d na posição %d, esperava %d: %v", tick, i, want, ticks)
+				}
+			}
+
+			excedente := fmt.Sprintf("%s.%d", caminho, c.copias+1)
+			if _, err := os.Stat(excedente); !os.IsNotExist(err) {
+				t.Fatalf("%s não deveria existir (err = %v)", excedente, err)
+			}
+		})
+	}
+}
+
+func TestDiarioAbrirGiraSessaoAnterior(t *testing.T) {
+	caminho := filepath.Join(t.TempDir(), "partida.log")
+	anterior, err := diarioAbrir(caminho, 1<<20, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anterior.Registrar("teste", nil)
+	anterior.Registrar("teste", nil)
+	if err := anterior.Fechar(); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := diarioAbrir(caminho, 1<<20, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Registrar("teste", nil)
+	if err := d.Fechar(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ticks := ticksDiario(t, caminho+".1"); len(ticks) != 2 {
+		t.Fatalf("a sessão anterior deveria estar em .1 com 2 entradas, tem %v", ticks)
+	}
+	if ticks := ticksDiario(t, caminho); len(ticks) != 1 || ticks[0] != 1 {
+		t.Fatalf("a sessão nova deveria começar do tick 1, tem %v", ticks)
+	}
+}
+
+func TestDiarioNilNaoGrava(t *testing.T) {
+	var d *Diario
+	d.Registrar("teste", nil)
+	if err := d.Fechar(); err != nil {
+		t.Fatalf("Fechar num diário nil: %v", err)
+	}
+}
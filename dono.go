@@ -0,0 +1,146 @@
+// dono.go - Goroutine dona do estado do jogo, que aplica as intenções dos elementos e tira instantâneos
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Intencao é uma alteração que uma goroutine pede ao dono do jogo. Só o dono
+// lê e altera o Jogo: as demais goroutines mandam funções para ele aplicar,
+// uma de cada vez, e esperam o fim da aplicação.
+type Intencao struct {
+	aplicar   func()
+	pedida    time.Time // quando a intenção foi enviada, para medir a espera
+	disputada bool      // o dono estava ocupado com outra intenção
+	feita     chan bool
+}
+
+// Instantaneo é uma cópia imutável do estado do jogo, feita pelo dono, que a
+// interface e os agentes leem à vontade sem parar os elementos
+type Instantaneo struct {
+	Quando         time.Duration // tempo da partida em que foi tirado
+	Mapa           [][]Elemento  // somente leitura: linhas compartilhadas entre instantâneos
+	Jogador        Ponto
+	Vida           int
+	Pontos         int
+	Stats          Estatisticas
+	EstadoGuardiao EstadoGuardiao
+	AlertaGuardiao bool
+	Terminou       bool
+	Status         string     // texto da mensagem mais recente
+	Recentes       []Mensagem // mensagens da barra de status
+
+	// Estado da tela
+	HistoricoAberto  bool
+	HistoricoRolagem int
+	Historico        []Mensagem // todas as mensagens, só com o histórico aberto
+	MinimapaVisivel  bool
+	Depuracao        []string // linhas do painel, só com o painel aberto
+}
+
+// Inicia a goroutine dona do jogo. Ela aplica as intenções na ordem em que
+// chegam até o contexto ser cancelado; depois disso as intenções são
+// recusadas.
+func jogoIniciarDono(ctx context.Context, jogo *Jogo) {
+	jogo.encerrado = ctx.Done()
+	jogo.grupo.Iniciar("dono", func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case i := <-jogo.intencoes:
+				if i.disputada {
+					espera := time.Since(i.pedida)
+					jogo.espera.Disputadas++
+					jogo.espera.Total += espera
+					jogo.espera.Ultima = espera
+					jogo.espera.Maxima = max(jogo.espera.Maxima, espera)
+				}
+				jogo.espera.Aplicadas++
+				i.aplicar()
+				close(i.feita)
+			}
+		}
+	})
+}
+
+// Pede ao dono que aplique a função ao jogo e espera a aplicação. Retorna
+// false se a partida já foi encerrada ou se o dono falhou durante a
+// aplicação. Não deve ser chamada de dentro de outra intenção, pois o dono
+// esperaria por si mesmo.
+func jogoExecutar(jogo *Jogo, aplicar func()) bool {
+	i := Intencao{aplicar: aplicar, pedida: time.Now(), feita: make(chan bool)}
+	select {
+	case jogo.intencoes <- i:
+	default:
+		// O dono está aplicando outra intenção: espera a vez
+		i.disputada = true
+		select {
+		case jogo.intencoes <- i:
+		case <-jogo.encerrado:
+			return false
+		}
+	}
+	select {
+	case <-i.feita:
+		return true
+	case <-jogo.encerrado:
+		// O dono pode ter entrado em pânico durante a aplicação
+		select {
+		case <-i.feita:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// Copia o mapa para um instantâneo (chamada pelo dono do jogo). As linhas
+// iguais às do instantâneo anterior são reaproveitadas em vez de copiadas:
+// os instantâneos não mudam, e quem desenha pula as linhas repetidas.
+func jogoCopiarMapa(jogo *Jogo) [][]Elemento {
+	copia := make([][]Elemento, len(jogo.Mapa))
+	for y, linha := range jogo.Mapa {
+		if y < len(jogo.copia) && slices.Equal(jogo.copia[y], linha) {
+			copia[y] = jogo.copia[y]
+		} else {
+			copia[y] = slices.Clone(linha)
+		}
+	}
+	jogo.copia = copia
+	return copia
+}
+
+// Tira um instantâneo do jogo; retorna nil se a partida já foi encerrada
+func jogoInstantaneo(jogo *Jogo) *Instantaneo {
+	var inst *Instantaneo
+	jogoExecutar(jogo, func() {
+		cfg := jogo.config.Mensagens
+		agora := jogo.relogio.Agora()
+		inst = &Instantaneo{
+			Quando:           agora,
+			Mapa:             jogoCopiarMapa(jogo),
+			Jogador:          Ponto{jogo.PosX, jogo.PosY},
+			Vida:             jogo.Vida,
+			Pontos:           jogo.Pontos,
+			Stats:            jogo.Stats,
+			EstadoGuardiao:   jogo.EstadoGuardiao,
+			AlertaGuardiao:   jogo.AlertaGuardiao,
+			Terminou:         jogoTerminou(jogo),
+			Status:           jogoStatus(jogo),
+			Recentes:         jogo.Mensagens.Recentes(agora, cfg.Linhas, cfg.TempoMinimo.Tempo()),
+			HistoricoAberto:  jogo.historicoAberto,
+			HistoricoRolagem: jogo.historicoRolagem,
+			MinimapaVisivel:  jogo.minimapaVisivel,
+		}
+		if jogo.historicoAberto {
+			inst.Historico = jogo.Mensagens.Todas()
+		}
+		if jogo.depuracaoVisivel {
+			inst.Depuracao = jogoDepuracao(jogo)
+		}
+	})
+	return inst
+}
@@ -1,417 +1,1186 @@
 package main
 
 import (
-	"math/rand"
+	"context"
+	"fmt"
+	"sort"
 	"time"
 )
 
 // Elementos visuais adicionais
 var (
 	Portal    = Elemento{'O', CorVerde, CorPadrao, false}       // Mudado para 'O' para compatibilidade
-	Armadilha = Elemento{'X', CorVermelho, CorPadrao, true}     // Mudado para 'X'
+	Armadilha = Elemento{'X', CorVermelho, CorPadrao, true}     // Mudado para 'X'; armadilha de espinhos
 	Fantasma  = Elemento{'G', CorCinzaEscuro, CorPadrao, false} // Mudado para 'G' (Ghost)
 	Tesouro   = Elemento{'$', CorVerde, CorPadrao, false}       // Mudado para '$'
 	Guardian  = Elemento{'@', CorVermelho, CorPadrao, true}     // Mudado para '@'
+
+	Perseguidor = Elemento{'&', CorVermelho, CorPadrao, true} // Membro de um enxame, definido no mapa
+
+	// Demais armadilhas. Como a de espinhos, bloqueiam os outros elementos,
+	// mas o jogador pode pisar nelas.
+	Laco           = Elemento{'∞', CorVermelho, CorPadrao, true}
+	Alarme         = Elemento{'♪', CorVermelho, CorPadrao, true}
+	Teleporte      = Elemento{'◎', CorVerde, CorPadrao, true}
+	ArmadilhaGasta = Elemento{'·', CorCinzaEscuro, CorPadrao, true} // disparou e espera o rearme
+
+	// Colocados pelo jogador. Os outros elementos pisam neles sem perceber.
+	ArmadilhaJogador = Elemento{'⊗', CorVerde, CorPadrao, false}
+	Isca             = Elemento{'¤', CorVerde, CorPadrao, false}
 )
 
-// Estruturas de mensagens para comunicação entre elementos
-type MsgPortal struct {
-	X, Y int
-	Cmd  string // "usar", "fechar"
-}
+// Nome de cada elemento do mapa nos temas e conjuntos de glifos, indexado
+// pelo símbolo
+var chavesElemento = map[rune]string{
+	Personagem.simbolo:  "personagem",
+	Inimigo.simbolo:     "inimigo",
+	Parede.simbolo:      "parede",
+	Vegetacao.simbolo:   "vegetacao",
+	Vazio.simbolo:       "vazio",
+	Portal.simbolo:      "portal",
+	Armadilha.simbolo:   "armadilha",
+	Fantasma.simbolo:    "fantasma",
+	Tesouro.simbolo:     "tesouro",
+	Guardian.simbolo:    "guardiao",
+	Perseguidor.simbolo: "perseguidor",
 
-type MsgArmadilha struct {
-	X, Y  int
-	Ativa bool
+	Laco.simbolo:           "laco",
+	Alarme.simbolo:         "alarme",
+	Teleporte.simbolo:      "teleporte",
+	ArmadilhaGasta.simbolo: "armadilha_gasta",
+
+	ArmadilhaJogador.simbolo: "armadilha_jogador",
+	Isca.simbolo:             "isca",
 }
 
-type MsgFantasma struct {
-	Cmd              string // "patrulhar", "perseguir", "ocultar"
-	PlayerX, PlayerY int
+func chaveElementoValida(chave string) bool {
+	for _, c := range chavesElemento {
+		if c == chave {
+			return true
+		}
+	}
+	return false
 }
 
-type MsgTesouro struct {
-	X, Y     int
-	Aparecer bool
+// Função auxiliar para verificar se posição é válida e segura. Mapas maiores
+// que o terminal são percorridos pela janela que acompanha o personagem.
+func posicaoValida(x, y int, jogo *Jogo) bool {
+	return dentroDoMapa(jogo.Mapa, Ponto{x, y})
 }
 
-type MsgGuardian struct {
-	Cmd              string // "dormir", "despertar", "atacar"
-	PlayerX, PlayerY int
-	Alerta           bool
+// Estado do inimigo de patrulha guardado no salvamento
+type patrulhaSalva struct {
+	Pos     Ponto    `json:"pos"`
+	Direcao int      `json:"direcao"`
+	Sob     Elemento `json:"sob"`
 }
 
-// Canal para exclusão mútua do mapa (proteção contra condições de corrida)
-var mapaMutex = make(chan bool, 1)
+// ELEMENTO 1: Inimigo Patrulha (melhorado com proteção)
+func iniciarInimigoPatrulha(ctx context.Context, jogo *Jogo, x, y int) {
+	ticker := jogo.relogio.pulsar(jogo.config.Patrulha.Intervalo.Tempo())
+	dx := 1
+	sob := Vazio // elemento que estava na célula ocupada pelo inimigo
+	var salvo patrulhaSalva
+	if jogoRestaurarElemento(jogo, "inimigo", &salvo) {
+		x, y, dx, sob = salvo.Pos.X, salvo.Pos.Y, salvo.Direcao, salvo.Sob
+	}
+	jogoSalvarElemento(jogo, "inimigo", func() any {
+		return patrulhaSalva{Pos: Ponto{x, y}, Direcao: dx, Sob: sob}
+	})
+	jogo.grupo.Iniciar("inimigo", func() {
+		defer ticker.Parar()
 
-// Função para inicializar o mutex do mapa
-func iniciarMutexMapa() {
-	mapaMutex <- true // Inicializa como disponível
-}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				jogoExecutar(jogo, func() {
+					// Atordoado por uma armadilha do jogador, fica parado
+					if jogoAtordoado(jogo, "inimigo") {
+						jogoInformarEstado(jogo, "inimigo", Ponto{x, y}, "estado.atordoado")
+						return
+					}
 
-// Função para obter acesso exclusivo ao mapa
-func obterAcessoMapa() {
-	<-mapaMutex
-}
+					// Verifica se posição atual é válida
+					if posicaoValida(x, y, jogo) {
+						novoX := x + dx
+						if posicaoValida(novoX, y, jogo) && jogoPodeMoverPara(jogo, novoX, y) {
+							// Remove inimigo da posição atual
+							if jogo.Mapa[y][x].simbolo == Inimigo.simbolo {
+								jogoDeixarCelula(jogo, Ponto{x, y}, sob)
+							}
+							x = novoX
+							sob = jogoOcuparCelula(jogo, Ponto{x, y}, Inimigo, "inimigo")
+						} else {
+							dx = -dx // Muda direção
+						}
+					}
+					jogoInformarEstado(jogo, "inimigo", Ponto{x, y}, "estado.patrulhando")
+				})
+				ticker.Concluir()
 
-// Função para liberar acesso ao mapa
-func liberarAcessoMapa() {
-	mapaMutex <- true
+			}
+		}
+	})
 }
 
-// Função auxiliar para verificar se posição é válida e segura
-func posicaoValida(x, y int, jogo *Jogo) bool {
-	return x >= 0 && x < len(jogo.Mapa[0]) && y >= 0 && y < len(jogo.Mapa) &&
-		x < 79 && y < 29 // Limites seguros do terminal
+// Estado de um inimigo de rota guardado no salvamento
+type rotaSalva struct {
+	Pos         Ponto         `json:"pos"`
+	Sob         Elemento      `json:"sob"`
+	Alvo        int           `json:"alvo"` // índice do ponto da rota para onde vai ou onde está parado
+	Sentido     int           `json:"sentido"`
+	Posicionado bool          `json:"posicionado"`
+	Parado      bool          `json:"parado"`
+	ParouEm     time.Duration `json:"parou_em"`
 }
 
-// ELEMENTO 1: Inimigo Patrulha (melhorado com proteção)
-func iniciarInimigoPatrulha(jogo *Jogo, x, y int, done chan bool) {
-	go func() {
-		dx := 1
-		ticker := time.NewTicker(800 * time.Millisecond) // Mais lento para evitar flickering
-		defer ticker.Stop()
+// Variante do ELEMENTO 1: inimigo que percorre uma rota definida no mapa.
+// indice distribui os inimigos da mesma rota ao longo dos seus pontos.
+func iniciarInimigoRota(ctx context.Context, jogo *Jogo, rota *RotaPatrulha, indice int) {
+	rng := jogoNovoAleatorio(jogo)
+	intervalo := rota.Intervalo
+	if intervalo == 0 {
+		intervalo = jogo.config.Patrulha.Intervalo.Tempo()
+	}
+	ticker := jogo.relogio.pulsar(intervalo)
+	nome := fmt.Sprintf("rota %s #%d", rota.Nome, indice+1)
+
+	// Ponto de partida e posicionamento inicial, feitos antes de iniciar a
+	// goroutine para que a ordem entre os inimigos seja sempre a mesma
+	alvo := indice * len(rota.Pontos) / max(rota.Inimigos, 1)
+	pos := rota.Pontos[alvo]
+	sob := Vazio // elemento que estava na célula ocupada pelo inimigo
+	posicionado := false
+	sentido := 1
+	var parouEm time.Duration // instante em que chegou ao ponto atual
+	parado := true            // começa parado no ponto de partida
+	var salvo rotaSalva
+	if jogoRestaurarElemento(jogo, nome, &salvo) && salvo.Alvo < len(rota.Pontos) {
+		// Continua de onde estava; o mapa salvo já o mostra lá
+		pos, sob, alvo, sentido = salvo.Pos, salvo.Sob, salvo.Alvo, salvo.Sentido
+		posicionado, parado, parouEm = salvo.Posicionado, salvo.Parado, salvo.ParouEm
+	} else {
+		jogoExecutar(jogo, func() {
+			if passavelTerreno(pos.X, pos.Y, jogo.Mapa[pos.Y][pos.X]) && pos != (Ponto{jogo.PosX, jogo.PosY}) {
+				sob = jogo.Mapa[pos.Y][pos.X]
+				jogo.Mapa[pos.Y][pos.X] = Inimigo
+				posicionado = true
+			}
+		})
+	}
+	jogoSalvarElemento(jogo, nome, func() any {
+		return rotaSalva{Pos: pos, Sob: sob, Alvo: alvo, Sentido: sentido, Posicionado: posicionado, Parado: parado, ParouEm: parouEm}
+	})
+
+	jogo.grupo.Iniciar(nome, func() {
+		atordoado, atraido := false, false
+		var isca Ponto // isca que tirou o inimigo da rota
+		buscador := buscadorNovo(passavelTerreno)
+		defer ticker.Parar()
 
 		for {
 			select {
-			case <-done:
+			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				obterAcessoMapa()
-
-				// Verifica se posição atual é válida
-				if !posicaoValida(x, y, jogo) {
-					liberarAcessoMapa()
-					continue
-				}
-
-				novoX := x + dx
-				if posicaoValida(novoX, y, jogo) && jogoPodeMoverPara(jogo, novoX, y) {
-					// Remove inimigo da posição atual
-					if jogo.Mapa[y][x].simbolo == Inimigo.simbolo {
-						jogo.Mapa[y][x] = Vazio
+				jogoExecutar(jogo, func() {
+					jogador := Ponto{jogo.PosX, jogo.PosY}
+					livre := func(p Ponto) bool {
+						return p != jogador && passavelTerreno(p.X, p.Y, jogo.Mapa[p.Y][p.X])
 					}
-					x = novoX
-					jogo.Mapa[y][x] = Inimigo
-				} else {
-					dx = -dx // Muda direção
-				}
 
-				liberarAcessoMapa()
+					if !posicionado {
+						// O ponto de partida estava ocupado; tenta de novo
+						if livre(pos) {
+							sob = jogo.Mapa[pos.Y][pos.X]
+							jogo.Mapa[pos.Y][pos.X] = Inimigo
+							posicionado = true
+							parouEm = jogo.relogio.Agora()
+						}
+					} else {
+						// Anda um passo em direção ao destino; se bloqueado, espera
+						andar := func(destino Ponto) {
+							passo, ok := buscador.ProximoPasso(jogo.Mapa, pos, destino)
+							if ok && livre(passo) {
+								jogoDeixarCelula(jogo, pos, sob)
+								pos = passo
+								sob = jogoOcuparCelula(jogo, pos, Inimigo, nome)
+							}
+						}
 
-				// Renderiza com delay para evitar spam
-				time.Sleep(50 * time.Millisecond)
-				interfaceDesenharJogo(jogo)
+						atordoado = jogoAtordoado(jogo, nome)
+						isca, atraido = jogoIscaProxima(jogo, pos, jogo.config.Isca.Raio)
+						switch {
+						case atordoado:
+							// Pisou numa armadilha do jogador: fica parado
+						case atraido:
+							// Uma isca tira o inimigo da rota até sumir
+							if pos != isca {
+								andar(isca)
+							}
+						default:
+							// Depois da pausa no ponto, segue para o próximo
+							if parado && jogo.relogio.Agora()-parouEm >= rota.Pausa {
+								alvo, sentido = rotaProximoPonto(rota, alvo, sentido, rng.Intn)
+								parado = false
+							}
+							if !parado {
+								andar(rota.Pontos[alvo])
+								if pos == rota.Pontos[alvo] {
+									parado = true
+									parouEm = jogo.relogio.Agora()
+								}
+							}
+						}
+					}
+					switch {
+					case !posicionado:
+						jogoInformarEstado(jogo, nome, pos, "estado.esperando_livre")
+					case atordoado:
+						jogoInformarEstado(jogo, nome, pos, "estado.atordoado")
+					case atraido:
+						jogoInformarEstado(jogo, nome, pos, "estado.atraido", isca.X, isca.Y)
+					case parado:
+						jogoInformarEstado(jogo, nome, pos, "estado.parado_ponto", alvo+1)
+					default:
+						jogoInformarEstado(jogo, nome, pos, "estado.indo_ponto", alvo+1)
+					}
+				})
+				ticker.Concluir()
 			}
 		}
-	}()
+	})
+}
+
+// Estado do portal guardado no salvamento
+type portalSalvo struct {
+	Aberto  bool          `json:"aberto"`
+	Pos     Ponto         `json:"pos"`
+	FechaEm time.Duration `json:"fecha_em"`
 }
 
 // ELEMENTO 2: Portal com Timeout (protegido contra corrupção)
-func iniciarPortal(jogo *Jogo, portalChan chan MsgPortal, done chan bool) {
-	go func() {
-		ticker := time.NewTicker(10 * time.Second) // Mais lento para melhor observação
-		defer ticker.Stop()
+func iniciarPortal(ctx context.Context, jogo *Jogo) {
+	rng := jogoNovoAleatorio(jogo)
+	ticker := jogo.relogio.pulsar(jogo.config.Portal.Intervalo.Tempo())
+	mensagens := jogo.relogio.pulsoMensagens()
+	pedidos := jogo.eventos.Inscrever(OpcoesInscricao{
+		Nome:   "portal",
+		Tipos:  []TipoEvento{EvJogadorInteragiu},
+		Filtro: filtroInteracao(Portal.simbolo),
+	})
+
+	// Portal aberto no momento; fechamento é nil quando não há portal
+	px, py := 0, 0
+	aberto := false
+	var fechaEm time.Duration // instante em que o portal aberto fecha
+	var fechamento *Pulso
+	var salvo portalSalvo
+	if jogoRestaurarElemento(jogo, "portal", &salvo) && salvo.Aberto {
+		px, py, aberto, fechaEm = salvo.Pos.X, salvo.Pos.Y, true, salvo.FechaEm
+		fechamento = jogo.relogio.apos(fechaEm - jogo.relogio.Agora())
+	}
+	jogoExecutar(jogo, func() {
+		if aberto {
+			jogoInformarEstado(jogo, "portal", Ponto{px, py}, "estado.portal_aberto")
+		} else {
+			jogoInformarEstado(jogo, "portal", semPosicao, "estado.portal_fechado")
+		}
+	})
+	jogoSalvarElemento(jogo, "portal", func() any {
+		return portalSalvo{Aberto: aberto, Pos: Ponto{px, py}, FechaEm: fechaEm}
+	})
+	jogo.grupo.Iniciar("portal", func() {
+		defer ticker.Parar()
+		defer mensagens.Parar()
+		defer pedidos.Cancelar()
+
+		usar := func(ev Evento) {
+			pedido := ev.(JogadorInteragiu)
+			if fechamento == nil || pedido.Pos != (Ponto{px, py}) {
+				return
+			}
+			fechamento.Parar()
+			fechamento = nil
+
+			jogoExecutar(jogo, func() {
+				aberto = false
+				jogoInformarEstado(jogo, "portal", semPosicao, "estado.portal_usado")
+				jogoMensagem(jogo, GravidadeInfo, "portal", "portal.usado")
+				jogo.Stats.PortaisUsados++
+				jogo.Mapa[py][px] = Vazio
+
+				// Teletransporta para posição segura
+				saida := jogoTeletransportar(jogo, rng)
+				jogoPublicar(jogo, PortalUsado{Entrada: pedido.Pos, Saida: saida})
+				jogoPublicar(jogo, JogadorMoveu{De: pedido.Pos, Para: saida})
+			})
+		}
 
 		for {
+			// Só espera o timeout enquanto houver um portal aberto
+			var timeout <-chan time.Time
+			if fechamento != nil {
+				timeout = fechamento.C
+			}
+
 			select {
-			case <-done:
+			case <-ctx.Done():
+				if fechamento != nil {
+					fechamento.Parar()
+				}
 				return
+			case <-entrada(jogo.relogio, pedidos.C):
+				pedidos.Drenar(usar)
+			case <-mensagens.C:
+				pedidos.Drenar(usar)
+				mensagens.Concluir()
 			case <-ticker.C:
 				// Tenta criar portal em posição aleatória (com limites seguros)
-				tentativas := 0
-				for tentativas < 20 {
-					px := 5 + rand.Intn(70) // Evita bordas
-					py := 5 + rand.Intn(20) // Evita bordas
-
-					obterAcessoMapa()
-					if posicaoValida(px, py, jogo) && jogoPodeMoverPara(jogo, px, py) {
-						jogo.Mapa[py][px] = Portal
-						jogo.StatusMsg = "Portal apareceu!"
-						liberarAcessoMapa()
-						interfaceDesenharJogo(jogo)
-
-						// Aguarda uso do portal ou timeout
-						portalUsado := false
-						select {
-						case msg := <-portalChan:
-							if msg.X == px && msg.Y == py && msg.Cmd == "usar" {
-								obterAcessoMapa()
-								jogo.StatusMsg = "Portal usado! Teletransporte!"
-								jogo.Mapa[py][px] = Vazio
-
-								// Teletransporta para posição segura
-								for i := 0; i < 10; i++ {
-									nx := 5 + rand.Intn(70)
-									ny := 5 + rand.Intn(20)
-									if posicaoValida(nx, ny, jogo) && jogoPodeMoverPara(jogo, nx, ny) {
-										jogo.PosX, jogo.PosY = nx, ny
-										break
-									}
-								}
-								liberarAcessoMapa()
-								portalUsado = true
-							}
-						case <-time.After(7 * time.Second):
-							obterAcessoMapa()
-							if jogo.Mapa[py][px].simbolo == Portal.simbolo {
-								jogo.Mapa[py][px] = Vazio
-								jogo.StatusMsg = "Portal fechou automaticamente"
-							}
-							liberarAcessoMapa()
-						}
+				for tentativas := 0; fechamento == nil && tentativas < 20; tentativas++ {
+					p := jogo.config.Controle.AreaSurgimento.Sortear(rng) // Evita bordas
+					x, y := p.X, p.Y
 
-						if portalUsado {
-							interfaceDesenharJogo(jogo)
+					jogoExecutar(jogo, func() {
+						if posicaoValida(x, y, jogo) && jogoPodeMoverPara(jogo, x, y) {
+							jogo.Mapa[y][x] = Portal
+							jogoInformarEstado(jogo, "portal", Ponto{x, y}, "estado.portal_aberto")
+							jogoMensagem(jogo, GravidadeInfo, "portal", "portal.apareceu")
+							px, py = x, y
+							jogoPublicar(jogo, PortalAberto{Pos: Ponto{x, y}})
+							// Aguarda uso do portal ou timeout
+							aberto = true
+							fechaEm = jogo.relogio.Agora() + jogo.config.Portal.Aberto.Tempo()
+							fechamento = jogo.relogio.apos(jogo.config.Portal.Aberto.Tempo())
 						}
-						break
-					} else {
-						liberarAcessoMapa()
-					}
-					tentativas++
+					})
 				}
+				ticker.Concluir()
+			case <-timeout:
+				jogoExecutar(jogo, func() {
+					aberto = false
+					if jogo.Mapa[py][px].simbolo == Portal.simbolo {
+						jogo.Mapa[py][px] = Vazio
+						jogoInformarEstado(jogo, "portal", semPosicao, "estado.portal_esgotado")
+						jogoMensagem(jogo, GravidadeInfo, "portal", "portal.fechou")
+						jogoPublicar(jogo, PortalFechado{Pos: Ponto{px, py}})
+					}
+				})
+				fechamento.Concluir()
+				fechamento = nil
 			}
 		}
-	}()
+	})
+}
+
+// Estado do fantasma guardado no salvamento. As ordens do controle central
+// chegam a cada pulso dele, então o fantasma não guarda se está perseguindo.
+type fantasmaSalvo struct {
+	Pos Ponto    `json:"pos"`
+	Sob Elemento `json:"sob"`
 }
 
 // ELEMENTO 3: Fantasma que Escuta Múltiplos Canais (simplificado)
-func iniciarFantasma(jogo *Jogo, fantasmaChan chan MsgFantasma, done chan bool) {
-	go func() {
-		x, y := 15, 15
-		visivel := true
+func iniciarFantasma(ctx context.Context, jogo *Jogo) {
+	rng := jogoNovoAleatorio(jogo)
+	ticker := jogo.relogio.pulsar(jogo.config.Fantasma.Intervalo.Tempo())
+	mensagens := jogo.relogio.pulsoMensagens()
+	ordens := jogo.eventos.Inscrever(OpcoesInscricao{
+		Nome:       "fantasma",
+		Tipos:      []TipoEvento{EvOrdemFantasma},
+		Capacidade: 5, // ordens antigas perdem o sentido; só as mais recentes importam
+	})
+
+	// Toca do fantasma, para onde ele volta depois de pegar o jogador
+	tocaX, tocaY := jogo.config.Fantasma.Toca.X, jogo.config.Fantasma.Toca.Y
+	x, y := tocaX, tocaY
+	sob := Vazio // elemento que estava na célula ocupada pelo fantasma
+	var salvo fantasmaSalvo
+	if jogoRestaurarElemento(jogo, "fantasma", &salvo) {
+		x, y, sob = salvo.Pos.X, salvo.Pos.Y, salvo.Sob
+	}
+	jogoSalvarElemento(jogo, "fantasma", func() any {
+		return fantasmaSalvo{Pos: Ponto{x, y}, Sob: sob}
+	})
+	jogo.grupo.Iniciar("fantasma", func() {
 		perseguindo := false
-		ticker := time.NewTicker(1 * time.Second) // Mais lento
-		defer ticker.Stop()
+		buscador := buscadorNovo(passavelPadrao)
+		defer ticker.Parar()
+		defer mensagens.Parar()
+		defer ordens.Cancelar()
+
+		tratar := func(ev Evento) {
+			perseguindo = ev.(OrdemFantasma).Comando == FantasmaPerseguir
+		}
 
 		for {
 			select {
-			case <-done:
+			case <-ctx.Done():
 				return
-			case msg := <-fantasmaChan:
-				switch msg.Cmd {
-				case "perseguir":
-					perseguindo = true
-					visivel = true
-				case "patrulhar":
-					perseguindo = false
-				case "ocultar":
-					visivel = false
-				}
+			case <-entrada(jogo.relogio, ordens.C):
+				ordens.Drenar(tratar)
+			case <-mensagens.C:
+				ordens.Drenar(tratar)
+				mensagens.Concluir()
 			case <-ticker.C:
-				obterAcessoMapa()
+				jogoExecutar(jogo, func() {
+					// Remove fantasma da posição atual, devolvendo o que havia embaixo
+					if posicaoValida(x, y, jogo) && jogo.Mapa[y][x].simbolo == Fantasma.simbolo {
+						jogoDeixarCelula(jogo, Ponto{x, y}, sob)
+					}
 
-				// Remove fantasma da posição atual se visível
-				if visivel && posicaoValida(x, y, jogo) && jogo.Mapa[y][x].simbolo == Fantasma.simbolo {
-					jogo.Mapa[y][x] = Vazio
-				}
+					// Atordoado por uma armadilha do jogador, fica parado
+					atordoado := jogoAtordoado(jogo, "fantasma")
+					novoX, novoY := x, y
+					switch {
+					case atordoado:
+					case perseguindo:
+						// Segue o caminho mais curto até o jogador, contornando paredes
+						jogador := Ponto{jogo.PosX, jogo.PosY}
+						if passo, ok := buscador.ProximoPasso(jogo.Mapa, Ponto{x, y}, jogador); ok {
+							novoX, novoY = passo.X, passo.Y
+						}
+					default:
+						// Movimento aleatório simples
+						moves := [][]int{{0, 1}, {0, -1}, {1, 0}, {-1, 0}, {0, 0}}
+						move := moves[rng.Intn(len(moves))]
+						novoX = x + move[0]
+						novoY = y + move[1]
+					}
 
-				// Movimento mais simples
-				novoX, novoY := x, y
-				if perseguindo {
-					// Move um passo em direção ao jogador
-					if jogo.PosX > x {
-						novoX = x + 1
-					} else if jogo.PosX < x {
-						novoX = x - 1
-					} else if jogo.PosY > y {
-						novoY = y + 1
-					} else if jogo.PosY < y {
-						novoY = y - 1
+					// Verifica se nova posição é válida
+					if posicaoValida(novoX, novoY, jogo) && jogoPodeMoverPara(jogo, novoX, novoY) {
+						x, y = novoX, novoY
 					}
-				} else {
-					// Movimento aleatório simples
-					moves := [][]int{{0, 1}, {0, -1}, {1, 0}, {-1, 0}, {0, 0}}
-					move := moves[rand.Intn(len(moves))]
-					novoX = x + move[0]
-					novoY = y + move[1]
+
+					// Pegou o jogador: tira uma vida e volta para a toca
+					if !atordoado && x == jogo.PosX && y == jogo.PosY && !jogoTerminou(jogo) {
+						jogoMensagem(jogo, GravidadePerigo, "fantasma", "fantasma.pegou")
+						jogo.Stats.CapturasFantasma++
+						jogoFerirPersonagem(jogo, "fantasma", jogo.config.Fantasma.Penalidade)
+						x, y = tocaX, tocaY
+					}
+
+					// Coloca fantasma na nova posição
+					if posicaoValida(x, y, jogo) {
+						sob = jogoOcuparCelula(jogo, Ponto{x, y}, Fantasma, "fantasma")
+					}
+					switch {
+					case atordoado:
+						jogoInformarEstado(jogo, "fantasma", Ponto{x, y}, "estado.atordoado")
+					case perseguindo:
+						jogoInformarEstado(jogo, "fantasma", Ponto{x, y}, "estado.perseguindo")
+					default:
+						jogoInformarEstado(jogo, "fantasma", Ponto{x, y}, "estado.vagando")
+					}
+				})
+				ticker.Concluir()
+			}
+		}
+	})
+}
+
+// ELEMENTO 4: Sistema de Armadilhas. Arma as armadilhas pedidas pelo
+// controle central e as colocadas pelo jogador, dispara a que o jogador
+// pisar e a rearma depois. Cuida também das iscas do jogador.
+func iniciarArmadilha(ctx context.Context, jogo *Jogo) {
+	rng := jogoNovoAleatorio(jogo)
+	mensagens := jogo.relogio.pulsoMensagens()
+	pedidos := jogo.eventos.Inscrever(OpcoesInscricao{
+		Nome:  "armadilha",
+		Tipos: []TipoEvento{EvPedidoArmadilha, EvPedidoIsca, EvJogadorMoveu},
+	})
+	jogoExecutar(jogo, func() {
+		jogoInformarEstado(jogo, "armadilha", semPosicao, "estado.aguardando")
+	})
+	jogo.grupo.Iniciar("armadilha", func() {
+		defer mensagens.Parar()
+		defer pedidos.Cancelar()
+
+		// No instante indicado, chama f pelo dono do jogo
+		depois := func(nome string, ate time.Duration, f func()) {
+			pulso := jogo.relogio.apos(ate - jogo.relogio.Agora())
+			jogo.grupo.Iniciar(nome, func() {
+				select {
+				case <-ctx.Done():
+					pulso.Parar()
+				case <-pulso.C:
+					jogoExecutar(jogo, f)
+					pulso.Concluir()
+				}
+			})
+		}
+
+		// Auto-desativação simplificada
+		expirar := func(armada *ArmadilhaInstalada) {
+			depois("expiração da armadilha", armada.Expira, func() {
+				if jogo.armadilhas[armada.Pos] != armada {
+					return
+				}
+				armadilhaRemover(jogo, armada.Pos)
+				jogoInformarEstado(jogo, "armadilha", armada.Pos, "estado.expirou")
+				if !armada.Oculta {
+					jogoMensagem(jogo, GravidadeInfo, "armadilha", "armadilha.expirou")
+				}
+				jogoPublicar(jogo, ArmadilhaDesarmada{Pos: armada.Pos})
+			})
+		}
+		rearmar := func(disparada *ArmadilhaInstalada) {
+			depois("rearme da armadilha", disparada.Rearme, func() {
+				if jogo.armadilhas[disparada.Pos] == disparada {
+					armadilhaRearmar(jogo, disparada)
+				}
+			})
+		}
+		sumir := func(pos Ponto, ate time.Duration) {
+			depois("isca", ate, func() {
+				if _, ok := jogo.iscas[pos]; !ok {
+					return
+				}
+				delete(jogo.iscas, pos)
+				if jogo.Mapa[pos.Y][pos.X].simbolo == Isca.simbolo {
+					jogo.Mapa[pos.Y][pos.X] = Vazio
+				}
+				jogoInformarEstado(jogo, "armadilha", pos, "estado.isca_sumiu")
+				jogoPublicar(jogo, IscaSumiu{Pos: pos})
+			})
+		}
+
+		// Numa partida carregada, retoma as expirações, os rearmes e as
+		// iscas que estavam pendentes, na ordem do mapa
+		var expirando, rearmando []*ArmadilhaInstalada
+		var iscas []iscaSalva
+		jogoExecutar(jogo, func() {
+			for _, a := range jogo.armadilhas {
+				if !a.DoJogador {
+					expirando = append(expirando, a)
+				}
+				if !a.Armada {
+					rearmando = append(rearmando, a)
 				}
+			}
+			for p, some := range jogo.iscas {
+				iscas = append(iscas, iscaSalva{Pos: p, Some: some})
+			}
+		})
+		sort.Slice(expirando, func(i, j int) bool { return pontoAntes(expirando[i].Pos, expirando[j].Pos) })
+		sort.Slice(rearmando, func(i, j int) bool { return pontoAntes(rearmando[i].Pos, rearmando[j].Pos) })
+		sort.Slice(iscas, func(i, j int) bool { return pontoAntes(iscas[i].Pos, iscas[j].Pos) })
+		for _, a := range expirando {
+			expirar(a)
+		}
+		for _, a := range rearmando {
+			rearmar(a)
+		}
+		for _, isca := range iscas {
+			sumir(isca.Pos, isca.Some)
+		}
 
-				// Verifica se nova posição é válida
-				if posicaoValida(novoX, novoY, jogo) && jogoPodeMoverPara(jogo, novoX, novoY) {
-					x, y = novoX, novoY
+		tratar := func(ev Evento) {
+			var armada, disparada *ArmadilhaInstalada
+			var isca *iscaSalva
+			jogoExecutar(jogo, func() {
+				// O jogador pôs algo sobre uma armadilha escondida: ela
+				// dispara nele e o item se perde
+				escondida := func(p Ponto) bool {
+					if a := jogo.armadilhas[p]; a == nil || !a.Oculta {
+						return false
+					}
+					jogoMensagem(jogo, GravidadePerigo, "armadilha", "armadilha.escondida")
+					disparada = armadilhaPisar(jogo, p, rng)
+					return true
 				}
 
-				// Coloca fantasma na posição se visível
-				if visivel && posicaoValida(x, y, jogo) {
-					jogo.Mapa[y][x] = Fantasma
+				switch msg := ev.(type) {
+				case JogadorMoveu:
+					disparada = armadilhaPisar(jogo, msg.Para, rng)
+				case PedidoIsca:
+					if !armadilhaPodeColocar(jogo, msg.Pos) {
+						jogo.Inventario.Iscas++ // devolve a isca que não coube
+						jogoMensagem(jogo, GravidadeInfo, "armadilha", "isca.recusada")
+						return
+					}
+					if escondida(msg.Pos) {
+						return
+					}
+					jogo.Mapa[msg.Pos.Y][msg.Pos.X] = Isca
+					jogo.iscas[msg.Pos] = jogo.relogio.Agora() + jogo.config.Isca.Duracao.Tempo()
+					jogoInformarEstado(jogo, "armadilha", msg.Pos, "estado.isca_colocada")
+					jogoMensagem(jogo, GravidadeInfo, "armadilha", "isca.colocada")
+					jogoPublicar(jogo, IscaColocada{Pos: msg.Pos})
+					isca = &iscaSalva{Pos: msg.Pos, Some: jogo.iscas[msg.Pos]}
+				case PedidoArmadilha:
+					// As do jogador vêm do inventário: qualquer recusa, mesmo
+					// fora do mapa, devolve o item. armadilhaPodeColocar já
+					// confere os limites.
+					if msg.DoJogador {
+						if !armadilhaPodeColocar(jogo, msg.Pos) {
+							jogo.Inventario.Armadilhas++ // devolve a armadilha que não coube
+							jogoMensagem(jogo, GravidadeInfo, "armadilha", "armadilha.recusada")
+							return
+						}
+						if escondida(msg.Pos) {
+							return
+						}
+						armadilhaInstalar(jogo, &ArmadilhaInstalada{Pos: msg.Pos, Tipo: msg.Armadilha, DoJogador: true})
+						jogoInformarEstado(jogo, "armadilha", msg.Pos, "estado.armadilha_jogador")
+						jogoMensagem(jogo, GravidadeInfo, "armadilha", "armadilha.colocada")
+						jogoPublicar(jogo, ArmadilhaArmada{Pos: msg.Pos, Armadilha: msg.Armadilha})
+						return
+					}
+					if !posicaoValida(msg.Pos.X, msg.Pos.Y, jogo) {
+						return
+					}
+					if msg.Ativa && msg.Pos.X == jogo.PosX && msg.Pos.Y == jogo.PosY {
+						// Armadilha surgiu debaixo do jogador: dispara na hora
+						if !jogoTerminou(jogo) {
+							jogoMensagem(jogo, GravidadePerigo, "armadilha", "armadilha.disparou")
+							armadilhaDisparar(jogo, msg.Armadilha, msg.Pos, rng)
+						}
+					} else if msg.Ativa && jogoPodeMoverPara(jogo, msg.Pos.X, msg.Pos.Y) && jogo.armadilhas[msg.Pos] == nil {
+						armada = armadilhaInstalar(jogo, &ArmadilhaInstalada{Pos: msg.Pos, Tipo: msg.Armadilha, Oculta: msg.Oculta})
+						armada.Expira = jogo.relogio.Agora() + jogo.config.Armadilha.Expiracao.Tempo()
+						jogoInformarEstado(jogo, "armadilha", msg.Pos, "estado.armou", msg.Armadilha.Nome())
+						if !msg.Oculta {
+							jogoMensagem(jogo, GravidadeAviso, "armadilha", "armadilha.ativada")
+						}
+						jogoPublicar(jogo, ArmadilhaArmada{Pos: msg.Pos, Armadilha: msg.Armadilha, Oculta: msg.Oculta})
+					} else if !msg.Ativa {
+						// Só o jogador desarma; as armadilhas dele voltam ao
+						// inventário, as do controle central só saem do mapa
+						if a := jogo.armadilhas[msg.Pos]; a != nil || elementoArmadilha(jogo.Mapa[msg.Pos.Y][msg.Pos.X]) {
+							armadilhaRemover(jogo, msg.Pos)
+							jogoInformarEstado(jogo, "armadilha", msg.Pos, "estado.desarmou")
+							if a != nil && a.DoJogador {
+								jogo.Inventario.Armadilhas++
+								jogoMensagem(jogo, GravidadeInfo, "armadilha", "armadilha.recolhida", jogo.Inventario.Armadilhas)
+							} else {
+								jogoMensagem(jogo, GravidadeInfo, "armadilha", "armadilha.desarmada")
+							}
+							jogoPublicar(jogo, ArmadilhaDesarmada{Pos: msg.Pos})
+						}
+					}
 				}
+			})
 
-				liberarAcessoMapa()
-				interfaceDesenharJogo(jogo)
+			if armada != nil {
+				expirar(armada)
+			}
+			if disparada != nil {
+				rearmar(disparada)
+			}
+			if isca != nil {
+				sumir(isca.Pos, isca.Some)
 			}
 		}
-	}()
-}
 
-// ELEMENTO 4: Sistema de Armadilhas (simplificado)
-func iniciarArmadilha(jogo *Jogo, armadilhaChan chan MsgArmadilha, done chan bool) {
-	go func() {
 		for {
 			select {
-			case <-done:
+			case <-ctx.Done():
 				return
-			case msg := <-armadilhaChan:
-				obterAcessoMapa()
-				if posicaoValida(msg.X, msg.Y, jogo) {
-					if msg.Ativa && jogoPodeMoverPara(jogo, msg.X, msg.Y) {
-						jogo.Mapa[msg.Y][msg.X] = Armadilha
-						jogo.StatusMsg = "Armadilha ativada!"
-					} else if !msg.Ativa {
-						if jogo.Mapa[msg.Y][msg.X].simbolo == Armadilha.simbolo {
-							jogo.Mapa[msg.Y][msg.X] = Vazio
-						}
-						jogo.StatusMsg = "Armadilha desarmada"
+			case <-entrada(jogo.relogio, pedidos.C):
+				pedidos.Drenar(tratar)
+			case <-mensagens.C:
+				pedidos.Drenar(tratar)
+				mensagens.Concluir()
+			}
+		}
+	})
+}
+
+// ELEMENTO 5: Tesouro (simplificado)
+func iniciarTesouro(ctx context.Context, jogo *Jogo) {
+	mensagens := jogo.relogio.pulsoMensagens()
+	pedidos := jogo.eventos.Inscrever(OpcoesInscricao{
+		Nome:   "tesouro",
+		Tipos:  []TipoEvento{EvPedidoTesouro, EvJogadorInteragiu},
+		Filtro: filtroInteracao(Tesouro.simbolo),
+	})
+	jogoExecutar(jogo, func() {
+		jogoInformarEstado(jogo, "tesouro", semPosicao, "estado.aguardando")
+	})
+	jogo.grupo.Iniciar("tesouro", func() {
+		defer mensagens.Parar()
+		defer pedidos.Cancelar()
+
+		tratar := func(ev Evento) {
+			jogoExecutar(jogo, func() {
+				switch msg := ev.(type) {
+				case PedidoTesouro:
+					// Faz surgir um tesouro
+					if posicaoValida(msg.Pos.X, msg.Pos.Y, jogo) && jogoPodeMoverPara(jogo, msg.Pos.X, msg.Pos.Y) {
+						jogo.Mapa[msg.Pos.Y][msg.Pos.X] = Tesouro
+						jogoInformarEstado(jogo, "tesouro", msg.Pos, "estado.surgiu")
+						jogoMensagem(jogo, GravidadeInfo, "tesouro", "tesouro.apareceu")
+						jogoPublicar(jogo, TesouroApareceu{Pos: msg.Pos})
+					}
+				case JogadorInteragiu:
+					// Coleta o tesouro sob o jogador
+					if posicaoValida(msg.Pos.X, msg.Pos.Y, jogo) && jogo.Mapa[msg.Pos.Y][msg.Pos.X].simbolo == Tesouro.simbolo {
+						jogo.Mapa[msg.Pos.Y][msg.Pos.X] = Vazio
+						jogoInformarEstado(jogo, "tesouro", msg.Pos, "estado.coletado")
+						jogoMensagem(jogo, GravidadeInfo, "tesouro", "tesouro.coletado")
+						jogo.Stats.TesourosColetados++
+						jogo.Pontos += jogo.config.Tesouro.Pontos
+						jogoPublicar(jogo, TesouroColetado{Pos: msg.Pos, Pontos: jogo.config.Tesouro.Pontos})
 					}
 				}
-				liberarAcessoMapa()
-				interfaceDesenharJogo(jogo)
-
-				// Auto-desativação simplificada
-				if msg.Ativa {
-					go func(x, y int) {
-						time.Sleep(6 * time.Second)
-						obterAcessoMapa()
-						if posicaoValida(x, y, jogo) && jogo.Mapa[y][x].simbolo == Armadilha.simbolo {
-							jogo.Mapa[y][x] = Vazio
-							jogo.StatusMsg = "Armadilha expirou"
-						}
-						liberarAcessoMapa()
-						interfaceDesenharJogo(jogo)
-					}(msg.X, msg.Y)
-				}
+			})
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-entrada(jogo.relogio, pedidos.C):
+				pedidos.Drenar(tratar)
+			case <-mensagens.C:
+				pedidos.Drenar(tratar)
+				mensagens.Concluir()
 			}
 		}
-	}()
+	})
 }
 
-// ELEMENTO 5: Tesouro (simplificado)
-func iniciarTesouro(jogo *Jogo, tesouroChan chan MsgTesouro, done chan bool) {
-	go func() {
+// Estados do guardião
+type EstadoGuardiao int
+
+const (
+	GuardiaoDormindo    EstadoGuardiao = iota // parado no posto, só desperta por mensagem ou com o jogador colado nele
+	GuardiaoDesconfiado                       // investiga o último lugar onde o jogador foi visto
+	GuardiaoPerseguindo                       // corta o caminho do jogador
+	GuardiaoAtacando                          // ao lado do jogador, atacando
+	GuardiaoRetornando                        // perdeu o jogador e volta para o posto
+)
+
+func (e EstadoGuardiao) String() string {
+	switch e {
+	case GuardiaoDesconfiado:
+		return "desconfiado"
+	case GuardiaoPerseguindo:
+		return "perseguindo"
+	case GuardiaoAtacando:
+		return "atacando"
+	case GuardiaoRetornando:
+		return "retornando"
+	}
+	return "dormindo"
+}
+
+// Nome do estado no idioma em uso, para exibir ao jogador
+func (e EstadoGuardiao) Nome() string {
+	return traduzir("guardiao.estado." + e.String())
+}
+
+// Estado do guardião guardado no salvamento
+type guardiaoSalvo struct {
+	Pos          Ponto          `json:"pos"`
+	Sob          Elemento       `json:"sob"`
+	Estado       EstadoGuardiao `json:"estado"`
+	Alerta       bool           `json:"alerta"`
+	Desde        time.Duration  `json:"desde"`
+	UltimoAtaque time.Duration  `json:"ultimo_ataque"`
+	UltimaVista  Ponto          `json:"ultima_vista"`
+	JogadorAntes Ponto          `json:"jogador_antes"`
+}
+
+// ELEMENTO 6: Guardião com máquina de estados
+func iniciarGuardian(ctx context.Context, jogo *Jogo) {
+	cfg := jogo.config.Guardiao
+	ticker := jogo.relogio.pulsar(cfg.Intervalo.Tempo())
+	mensagens := jogo.relogio.pulsoMensagens()
+	ordens := jogo.eventos.Inscrever(OpcoesInscricao{
+		Nome:       "guardiao",
+		Tipos:      []TipoEvento{EvOrdemGuardiao},
+		Capacidade: 5,
+	})
+	posto := cfg.Posto
+	x, y := posto.X, posto.Y
+	estado := GuardiaoDormindo
+	alerta := false                   // recebeu um alarme: sabe onde o jogador está
+	desde := jogo.relogio.Agora()     // instante em que entrou no estado atual
+	ultimoAtaque := time.Duration(-1) // instante do último golpe
+	ultimaVista := posto              // último lugar onde viu o jogador
+	sob := Vazio                      // elemento que estava na célula ocupada pelo guardião
+	jogadorAntes := Ponto{-1, -1}
+
+	var salvo guardiaoSalvo
+	if jogoRestaurarElemento(jogo, "guardiao", &salvo) {
+		// Continua de onde estava; o mapa salvo já o mostra lá
+		x, y, sob = salvo.Pos.X, salvo.Pos.Y, salvo.Sob
+		estado, alerta, desde = salvo.Estado, salvo.Alerta, salvo.Desde
+		ultimoAtaque, ultimaVista, jogadorAntes = salvo.UltimoAtaque, salvo.UltimaVista, salvo.JogadorAntes
+	} else {
+		// Coloca guardião no mapa
+		jogoExecutar(jogo, func() {
+			if posicaoValida(x, y, jogo) {
+				jogo.Mapa[y][x] = Guardian
+			}
+			jogo.EstadoGuardiao = GuardiaoDormindo
+		})
+	}
+	jogoSalvarElemento(jogo, "guardiao", func() any {
+		return guardiaoSalvo{
+			Pos: Ponto{x, y}, Sob: sob, Estado: estado, Alerta: alerta, Desde: desde,
+			UltimoAtaque: ultimoAtaque, UltimaVista: ultimaVista, JogadorAntes: jogadorAntes,
+		}
+	})
+
+	jogo.grupo.Iniciar("guardiao", func() {
+		buscador := buscadorNovo(passavelTerreno)
+		defer ticker.Parar()
+		defer mensagens.Parar()
+		defer ordens.Cancelar()
+
+		// Informa o estado ao painel de depuração (dentro de uma intenção)
+		informar := func() {
+			sufixoAlerta, sufixoAtordoado := "", ""
+			if alerta {
+				sufixoAlerta = traduzir("estado.sufixo_alerta")
+			}
+			if jogoAtordoado(jogo, "guardiao") {
+				sufixoAtordoado = traduzir("estado.sufixo_atordoado")
+			}
+			jogoInformarEstado(jogo, "guardiao", Ponto{x, y}, "estado.guardiao", estado.Nome(), sufixoAlerta, sufixoAtordoado)
+		}
+
+		// Troca de estado e publica no jogo para a barra de status (dentro de uma intenção)
+		mudar := func(novo EstadoGuardiao, msg string) {
+			if novo == GuardiaoDormindo || novo == GuardiaoRetornando {
+				alerta = false
+			}
+			estado = novo
+			desde = jogo.relogio.Agora()
+			jogo.EstadoGuardiao = estado
+			jogo.AlertaGuardiao = alerta
+			informar()
+			jogoPublicar(jogo, GuardiaoMudouEstado{Estado: estado, Alerta: alerta})
+			if msg != "" {
+				jogoMensagem(jogo, GravidadeAviso, "guardiao", msg)
+			}
+		}
+
+		// Dá um passo em direção ao destino (dentro de uma intenção); retorna false se não conseguiu
+		andar := func(destino Ponto, jogador Ponto) bool {
+			passo, ok := buscador.ProximoPasso(jogo.Mapa, Ponto{x, y}, destino)
+			if !ok || passo == jogador || !posicaoValida(passo.X, passo.Y, jogo) ||
+				!passavelTerreno(passo.X, passo.Y, jogo.Mapa[passo.Y][passo.X]) {
+				return false
+			}
+			jogoDeixarCelula(jogo, Ponto{x, y}, sob)
+			x, y = passo.X, passo.Y
+			sob = jogoOcuparCelula(jogo, Ponto{x, y}, Guardian, "guardiao")
+			return true
+		}
+
+		tratar := func(ev Evento) {
+			msg := ev.(OrdemGuardiao)
+			jogoExecutar(jogo, func() {
+				jogador := msg.Jogador
+				if msg.Alerta {
+					alerta = true
+					ultimaVista = jogador
+				}
+				if estado == GuardiaoDormindo || estado == GuardiaoRetornando {
+					ultimaVista = jogador
+					mudar(GuardiaoDesconfiado, "guardiao.despertou")
+				}
+				if alerta && estado == GuardiaoDesconfiado {
+					mudar(GuardiaoPerseguindo, "guardiao.alarme")
+				}
+				jogo.AlertaGuardiao = alerta
+			})
+		}
+
 		for {
 			select {
-			case <-done:
+			case <-ctx.Done():
 				return
-			case msg := <-tesouroChan:
-				obterAcessoMapa()
-				if posicaoValida(msg.X, msg.Y, jogo) {
-					if msg.Aparecer && jogoPodeMoverPara(jogo, msg.X, msg.Y) {
-						jogo.Mapa[msg.Y][msg.X] = Tesouro
-						jogo.StatusMsg = "Tesouro apareceu!"
-					} else if !msg.Aparecer {
-						if jogo.Mapa[msg.Y][msg.X].simbolo == Tesouro.simbolo {
-							jogo.Mapa[msg.Y][msg.X] = Vazio
+			case <-entrada(jogo.relogio, ordens.C):
+				ordens.Drenar(tratar)
+			case <-mensagens.C:
+				ordens.Drenar(tratar)
+				mensagens.Concluir()
+			case <-ticker.C:
+				jogoExecutar(jogo, func() {
+					// Atordoado por uma armadilha do jogador: não anda nem ataca
+					if jogoAtordoado(jogo, "guardiao") {
+						informar()
+						return
+					}
+
+					agora := jogo.relogio.Agora()
+					jogador := Ponto{jogo.PosX, jogo.PosY}
+					if jogadorAntes.X < 0 {
+						jogadorAntes = jogador
+					}
+					eu := Ponto{x, y}
+					distancia := heuristicaChebyshev(eu, jogador)
+					visivel := distancia <= cfg.RaioVisao && linhaDeVisao(jogo.Mapa, eu, jogador)
+					if visivel {
+						ultimaVista = jogador
+					}
+					vivo := !jogoTerminou(jogo)
+
+					switch estado {
+					case GuardiaoDormindo:
+						// Dormindo só percebe quem encosta nele
+						if vivo && distancia <= 1 {
+							mudar(GuardiaoPerseguindo, "guardiao.acordou")
+						}
+
+					case GuardiaoDesconfiado:
+						if vivo && visivel {
+							mudar(GuardiaoPerseguindo, "guardiao.detectou")
+						} else if eu == ultimaVista || agora-desde > cfg.TempoDesconfianca.Tempo() || !andar(ultimaVista, jogador) {
+							mudar(GuardiaoRetornando, "guardiao.desistiu")
+						}
+
+					case GuardiaoPerseguindo:
+						enxerga := distancia <= cfg.RaioPerseguicao && linhaDeVisao(jogo.Mapa, eu, jogador)
+						switch {
+						case !vivo:
+							mudar(GuardiaoRetornando, "")
+						case distancia <= 1:
+							mudar(GuardiaoAtacando, "")
+						case !enxerga && !alerta:
+							// Perdeu o jogador de vista: vai até onde o viu por último
+							mudar(GuardiaoDesconfiado, "guardiao.perdeu")
+						case agora-desde > cfg.TempoPerseguicao.Tempo():
+							mudar(GuardiaoRetornando, "guardiao.cansou")
+						default:
+							if enxerga {
+								ultimaVista = jogador
+							}
+							// Corta o caminho do jogador, parando ao lado dele
+							alvo := pontoInterceptacao(jogo.Mapa, eu, ultimaVista, jogadorAntes)
+							if !andar(alvo, jogador) {
+								andar(ultimaVista, jogador)
+							}
+						}
+
+					case GuardiaoAtacando:
+						if !vivo {
+							mudar(GuardiaoRetornando, "")
+						} else if distancia > 1 {
+							mudar(GuardiaoPerseguindo, "")
+						} else if ultimoAtaque < 0 || agora-ultimoAtaque >= cfg.IntervaloAtaque.Tempo() {
+							ultimoAtaque = agora
+							jogoMensagem(jogo, GravidadePerigo, "guardiao", "guardiao.golpe")
+							jogo.Stats.AtaquesGuardiao++
+							jogoFerirPersonagem(jogo, "guardiao", jogo.config.Guardiao.Penalidade)
+						}
+
+					case GuardiaoRetornando:
+						if vivo && visivel {
+							mudar(GuardiaoPerseguindo, "guardiao.viu")
+						} else if eu == posto || !andar(posto, jogador) {
+							if eu != posto && heuristicaManhattan(eu, posto) > 1 {
+								break // caminho bloqueado: tenta de novo no próximo pulso
+							}
+							mudar(GuardiaoDormindo, "guardiao.adormeceu")
 						}
-						jogo.StatusMsg = "Tesouro coletado!"
 					}
-				}
-				liberarAcessoMapa()
-				interfaceDesenharJogo(jogo)
+
+					jogadorAntes = jogador
+					informar()
+				})
+				ticker.Concluir()
 			}
 		}
-	}()
+	})
 }
 
-// ELEMENTO 6: Guardião (simplificado)
-func iniciarGuardian(jogo *Jogo, guardianChan chan MsgGuardian, done chan bool) {
-	go func() {
-		x, y := 25, 10
-		dormindo := true
-		ticker := time.NewTicker(2 * time.Second)
-		defer ticker.Stop()
+// Estado do enxame guardado no salvamento
+type enxameSalvo struct {
+	Dispersao     int                `json:"dispersao"`
+	Perseguidores []perseguidorSalvo `json:"perseguidores"`
+}
 
-		// Coloca guardião no mapa
-		obterAcessoMapa()
-		if posicaoValida(x, y, jogo) {
-			jogo.Mapa[y][x] = Guardian
+type perseguidorSalvo struct {
+	Pos    Ponto    `json:"pos"`
+	Origem Ponto    `json:"origem"`
+	Sob    Elemento `json:"sob"`
+}
+
+// ELEMENTO 7: Enxame de perseguidores guiados por um mapa de fluxo compartilhado
+func iniciarEnxame(ctx context.Context, jogo *Jogo) {
+	type perseguidor struct {
+		pos, origem Ponto
+		sob         Elemento // elemento que estava na célula ocupada
+		nome        string   // identifica o perseguidor no atordoamento
+	}
+
+	// Pulsos restantes em que o enxame se dispersa depois de pegar o jogador
+	dispersao := 0
+
+	// Localiza os perseguidores definidos no arquivo do mapa; numa partida
+	// carregada eles continuam de onde estavam
+	var enxame []*perseguidor
+	var salvo enxameSalvo
+	if jogoRestaurarElemento(jogo, "enxame", &salvo) {
+		dispersao = salvo.Dispersao
+		for _, p := range salvo.Perseguidores {
+			nome := fmt.Sprintf("enxame #%d", len(enxame)+1)
+			enxame = append(enxame, &perseguidor{pos: p.Pos, origem: p.Origem, sob: p.Sob, nome: nome})
 		}
-		liberarAcessoMapa()
+	} else {
+		jogoExecutar(jogo, func() {
+			for y, linha := range jogo.Mapa {
+				for x, elem := range linha {
+					if elem.simbolo == Perseguidor.simbolo {
+						nome := fmt.Sprintf("enxame #%d", len(enxame)+1)
+						enxame = append(enxame, &perseguidor{pos: Ponto{x, y}, origem: Ponto{x, y}, sob: Vazio, nome: nome})
+					}
+				}
+			}
+		})
+	}
+	if len(enxame) == 0 {
+		return
+	}
+	jogoSalvarElemento(jogo, "enxame", func() any {
+		salvo := enxameSalvo{Dispersao: dispersao}
+		for _, p := range enxame {
+			salvo.Perseguidores = append(salvo.Perseguidores, perseguidorSalvo{Pos: p.pos, Origem: p.origem, Sob: p.sob})
+		}
+		return salvo
+	})
+
+	ticker := jogo.relogio.pulsar(jogo.config.Enxame.Intervalo.Tempo())
+	jogo.grupo.Iniciar("enxame", func() {
+		defer ticker.Parar()
 
 		for {
 			select {
-			case <-done:
+			case <-ctx.Done():
 				return
-			case msg := <-guardianChan:
-				switch msg.Cmd {
-				case "despertar":
-					dormindo = false
-					jogo.StatusMsg = "Guardião despertou!"
-				case "dormir":
-					dormindo = true
-					jogo.StatusMsg = "Guardião adormeceu"
-				}
 			case <-ticker.C:
-				if !dormindo && posicaoValida(x, y, jogo) {
-					// Verifica proximidade do jogador
-					distX := abs(jogo.PosX - x)
-					distY := abs(jogo.PosY - y)
+				jogoExecutar(jogo, func() {
+					// Todos descem o mesmo mapa, calculado uma vez por posição do jogador
+					fluxo := jogoFluxoPerseguicao(jogo)
+					if dispersao > 0 {
+						fluxo = jogoFluxoFuga(jogo)
+						dispersao--
+					}
+					jogador := Ponto{jogo.PosX, jogo.PosY}
+					livre := func(p Ponto) bool {
+						return posicaoValida(p.X, p.Y, jogo) && passavelTerreno(p.X, p.Y, jogo.Mapa[p.Y][p.X])
+					}
+
+					atordoados := 0
+					for _, p := range enxame {
+						if jogoAtordoado(jogo, p.nome) {
+							atordoados++
+							continue
+						}
+						prox, ok := fluxo.ProximoPasso(p.pos, livre)
+						if !ok {
+							continue
+						}
+						jogoDeixarCelula(jogo, p.pos, p.sob)
+						p.pos = prox
+						p.sob = jogoOcuparCelula(jogo, prox, Perseguidor, p.nome)
 
-					if distX <= 3 && distY <= 3 {
-						jogo.StatusMsg = "Guardião te detectou!"
+						// Alcançou o jogador: fere, volta para a origem e o enxame se dispersa
+						if prox == jogador && dispersao == 0 && !jogoTerminou(jogo) {
+							jogoMensagem(jogo, GravidadePerigo, "enxame", "enxame.alcancou")
+							jogo.Stats.CapturasEnxame++
+							jogoFerirPersonagem(jogo, "enxame", jogo.config.Enxame.Penalidade)
+							dispersao = jogo.config.Enxame.Dispersao
+							if livre(p.origem) {
+								jogoDeixarCelula(jogo, p.pos, p.sob)
+								p.pos = p.origem
+								p.sob = jogoOcuparCelula(jogo, p.pos, Perseguidor, p.nome)
+							}
+						}
 					}
-				}
-				interfaceDesenharJogo(jogo)
+					switch {
+					case dispersao > 0:
+						jogoInformarEstado(jogo, "enxame", semPosicao, "estado.enxame_dispersando", len(enxame), dispersao)
+					case atordoados > 0:
+						jogoInformarEstado(jogo, "enxame", semPosicao, "estado.enxame_atordoados", len(enxame)-atordoados, atordoados)
+					default:
+						jogoInformarEstado(jogo, "enxame", semPosicao, "estado.enxame_perseguindo", len(enxame))
+					}
+				})
+				ticker.Concluir()
 			}
 		}
-	}()
+	})
+}
+
+// Estado do controle central guardado no salvamento
+type controleSalvo struct {
+	NaArea int `json:"na_area"`
 }
 
 // SISTEMA DE CONTROLE CENTRAL (simplificado)
-func iniciarControleCentral(jogo *Jogo, fantasmaChan chan MsgFantasma, guardianChan chan MsgGuardian,
-	tesouroChan chan MsgTesouro, armadilhaChan chan MsgArmadilha, done chan bool) {
-	go func() {
-		ticker := time.NewTicker(3 * time.Second) // Mais lento
-		defer ticker.Stop()
+func iniciarControleCentral(ctx context.Context, jogo *Jogo) {
+	rng := jogoNovoAleatorio(jogo)
+	cfg := jogo.config.Controle
+	ticker := jogo.relogio.pulsar(cfg.Intervalo.Tempo())
+
+	// Pulsos seguidos em que o jogador está na área do guardião
+	naArea := 0
+	var salvo controleSalvo
+	if jogoRestaurarElemento(jogo, "controle", &salvo) {
+		naArea = salvo.NaArea
+	}
+	jogoSalvarElemento(jogo, "controle", func() any {
+		return controleSalvo{NaArea: naArea}
+	})
+
+	jogo.grupo.Iniciar("controle", func() {
+		defer ticker.Parar()
 
 		for {
 			select {
-			case <-done:
+			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				// Controle do fantasma baseado na posição do jogador
-				if jogo.PosX > 30 {
-					select {
-					case fantasmaChan <- MsgFantasma{Cmd: "perseguir", PlayerX: jogo.PosX, PlayerY: jogo.PosY}:
-					default:
+				var posX, posY int
+				jogoExecutar(jogo, func() {
+					posX, posY = jogo.PosX, jogo.PosY
+					if cfg.AreaGuardiao.Contem(Ponto{posX, posY}) {
+						naArea++
+					} else {
+						naArea = 0
 					}
+					jogoInformarEstado(jogo, "controle", semPosicao, "estado.controle_area", naArea)
+				})
+				jogador := Ponto{posX, posY}
+
+				// Controle do fantasma baseado na posição do jogador
+				if posX > cfg.PerseguirAposX {
+					jogoPublicar(jogo, OrdemFantasma{Comando: FantasmaPerseguir, Jogador: jogador})
 				} else {
-					select {
-					case fantasmaChan <- MsgFantasma{Cmd: "patrulhar"}:
-					default:
-					}
+					jogoPublicar(jogo, OrdemFantasma{Comando: FantasmaPatrulhar})
 				}
 
-				// Controle do guardião
-				if jogo.PosX > 20 && jogo.PosY < 15 {
-					select {
-					case guardianChan <- MsgGuardian{Cmd: "despertar", PlayerX: jogo.PosX, PlayerY: jogo.PosY}:
-					default:
-					}
+				// Controle do guardião: quem insiste em ficar na área dispara o alarme
+				if naArea > 0 {
+					jogoPublicar(jogo, OrdemGuardiao{Jogador: jogador, Alerta: naArea >= cfg.PulsosAlarme})
 				}
 
 				// Spawna elementos com menor frequência
-				if rand.Intn(20) == 0 {
-					tx := 5 + rand.Intn(70)
-					ty := 5 + rand.Intn(20)
-					select {
-					case tesouroChan <- MsgTesouro{X: tx, Y: ty, Aparecer: true}:
-					default:
-					}
+				if rng.Intn(jogo.config.Tesouro.Chance) == 0 {
+					jogoPublicar(jogo, PedidoTesouro{Pos: cfg.AreaSurgimento.Sortear(rng)})
 				}
 
-				if rand.Intn(25) == 0 {
-					ax := jogo.PosX + rand.Intn(5) - 2
-					ay := jogo.PosY + rand.Intn(5) - 2
-					if posicaoValida(ax, ay, jogo) {
-						select {
-						case armadilhaChan <- MsgArmadilha{X: ax, Y: ay, Ativa: true}:
-						default:
-						}
-					}
+				if raio := jogo.config.Armadilha.Raio; rng.Intn(jogo.config.Armadilha.Chance) == 0 {
+					ax := posX + rng.Intn(2*raio+1) - raio
+					ay := posY + rng.Intn(2*raio+1) - raio
+					tipo := tiposArmadilha[rng.Intn(len(tiposArmadilha))]
+					oculta := rng.Intn(jogo.config.Armadilha.Oculta) == 0
+					jogoPublicar(jogo, PedidoArmadilha{Pos: Ponto{ax, ay}, Ativa: true, Armadilha: tipo, Oculta: oculta})
 				}
+				ticker.Concluir()
 			}
 		}
-	}()
+	})
 }
 
 // Função auxiliar para valor absoluto
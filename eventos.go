@@ -0,0 +1,178 @@
+// eventos.go - Eventos trocados pelos elementos através do barramento
+package main
+
+import (
+	"context"
+	"sort"
+)
+
+// TipoEvento identifica o tipo de um evento, usado nos filtros e relatórios
+type TipoEvento string
+
+// Evento é qualquer mensagem publicada no barramento
+type Evento interface {
+	Tipo() TipoEvento
+}
+
+// Tipos de evento
+const (
+	EvJogadorMoveu        TipoEvento = "jogador_moveu"
+	EvJogadorInteragiu    TipoEvento = "jogador_interagiu"
+	EvJogadorFerido       TipoEvento = "jogador_ferido"
+	EvTesouroApareceu     TipoEvento = "tesouro_apareceu"
+	EvTesouroColetado     TipoEvento = "tesouro_coletado"
+	EvPortalAberto        TipoEvento = "portal_aberto"
+	EvPortalFechado       TipoEvento = "portal_fechado"
+	EvPortalUsado         TipoEvento = "portal_usado"
+	EvArmadilhaArmada     TipoEvento = "armadilha_armada"
+	EvArmadilhaDisparada  TipoEvento = "armadilha_disparada"
+	EvArmadilhaDesarmada  TipoEvento = "armadilha_desarmada"
+	EvIscaColocada        TipoEvento = "isca_colocada"
+	EvIscaSumiu           TipoEvento = "isca_sumiu"
+	EvGuardiaoMudouEstado TipoEvento = "guardiao_mudou_estado"
+	EvOrdemFantasma       TipoEvento = "ordem_fantasma"
+	EvOrdemGuardiao       TipoEvento = "ordem_guardiao"
+	EvPedidoTesouro       TipoEvento = "pedido_tesouro"
+	EvPedidoArmadilha     TipoEvento = "pedido_armadilha"
+	EvPedidoIsca          TipoEvento = "pedido_isca"
+)
+
+// Fatos: o que aconteceu no jogo
+
+type JogadorMoveu struct{ De, Para Ponto }
+
+type JogadorInteragiu struct {
+	Pos     Ponto
+	Simbolo rune // elemento sob o jogador no momento da interação
+}
+
+type JogadorFerido struct {
+	Causa      string // "armadilha", "fantasma", "enxame" ou "guardiao"
+	Penalidade int
+	Vida       int // vidas restantes depois do golpe
+}
+
+type TesouroApareceu struct{ Pos Ponto }
+
+type TesouroColetado struct {
+	Pos    Ponto
+	Pontos int
+}
+
+type PortalAberto struct{ Pos Ponto }
+
+type PortalFechado struct{ Pos Ponto }
+
+type PortalUsado struct{ Entrada, Saida Ponto }
+
+type ArmadilhaArmada struct {
+	Pos       Ponto
+	Armadilha TipoArmadilha
+	Oculta    bool
+}
+
+type ArmadilhaDisparada struct {
+	Pos       Ponto
+	Armadilha TipoArmadilha
+	Alvo      string // quem pisou nela, pela chave do elemento ("personagem", "inimigo"...)
+}
+
+type ArmadilhaDesarmada struct{ Pos Ponto }
+
+type IscaColocada struct{ Pos Ponto }
+
+type IscaSumiu struct{ Pos Ponto }
+
+type GuardiaoMudouEstado struct {
+	Estado EstadoGuardiao
+	Alerta bool
+}
+
+// Ordens e pedidos: o que um elemento pede para outro fazer
+
+// Comportamentos que o controle central pode impor ao fantasma
+type ComandoFantasma int
+
+const (
+	FantasmaPatrulhar ComandoFantasma = iota
+	FantasmaPerseguir
+)
+
+type OrdemFantasma struct {
+	Comando ComandoFantasma
+	Jogador Ponto
+}
+
+// Ordem para o guardião despertar e ir atrás do jogador
+type OrdemGuardiao struct {
+	Jogador Ponto
+	Alerta  bool // o jogador insiste em ficar na área: persegue sem desconfiar
+}
+
+type PedidoTesouro struct{ Pos Ponto }
+
+type PedidoArmadilha struct {
+	Pos       Ponto
+	Ativa     bool // false desarma a armadilha na posição
+	Armadilha TipoArmadilha
+	Oculta    bool // só aparece no mapa quando o jogador a encontra ou pisa nela
+	DoJogador bool // tirada do inventário do jogador
+}
+
+type PedidoIsca struct{ Pos Ponto }
+
+func (JogadorMoveu) Tipo() TipoEvento        { return EvJogadorMoveu }
+func (JogadorInteragiu) Tipo() TipoEvento    { return EvJogadorInteragiu }
+func (JogadorFerido) Tipo() TipoEvento       { return EvJogadorFerido }
+func (TesouroApareceu) Tipo() TipoEvento     { return EvTesouroApareceu }
+func (TesouroColetado) Tipo() TipoEvento     { return EvTesouroColetado }
+func (PortalAberto) Tipo() TipoEvento        { return EvPortalAberto }
+func (PortalFechado) Tipo() TipoEvento       { return EvPortalFechado }
+func (PortalUsado) Tipo() TipoEvento         { return EvPortalUsado }
+func (ArmadilhaArmada) Tipo() TipoEvento     { return EvArmadilhaArmada }
+func (ArmadilhaDisparada) Tipo() TipoEvento  { return EvArmadilhaDisparada }
+func (ArmadilhaDesarmada) Tipo() TipoEvento  { return EvArmadilhaDesarmada }
+func (IscaColocada) Tipo() TipoEvento        { return EvIscaColocada }
+func (IscaSumiu) Tipo() TipoEvento           { return EvIscaSumiu }
+func (GuardiaoMudouEstado) Tipo() TipoEvento { return EvGuardiaoMudouEstado }
+func (OrdemFantasma) Tipo() TipoEvento       { return EvOrdemFantasma }
+func (OrdemGuardiao) Tipo() TipoEvento       { return EvOrdemGuardiao }
+func (PedidoTesouro) Tipo() TipoEvento       { return EvPedidoTesouro }
+func (PedidoArmadilha) Tipo() TipoEvento     { return EvPedidoArmadilha }
+func (PedidoIsca) Tipo() TipoEvento          { return EvPedidoIsca }
+
+// Publica um evento no barramento do jogo
+func jogoPublicar(jogo *Jogo, ev Evento) {
+	jogo.eventos.Publicar(ev)
+}
+
+// Filtro que aceita só interações do jogador sobre o elemento indicado
+func filtroInteracao(simbolo rune) func(ev Evento) bool {
+	return func(ev Evento) bool {
+		interacao, ok := ev.(JogadorInteragiu)
+		return !ok || interacao.Simbolo == simbolo
+	}
+}
+
+// Comportamentos extras: elementos que só reagem a eventos e não precisam de
+// canais próprios. Um arquivo novo pode registrar o seu em init() e ele será
+// iniciado em toda partida, sem mudanças em main ou em partidaNova.
+var comportamentos = map[string]func(ctx context.Context, jogo *Jogo){}
+
+// Registra um comportamento com o nome dado
+func registrarComportamento(nome string, iniciar func(ctx context.Context, jogo *Jogo)) {
+	comportamentos[nome] = iniciar
+}
+
+// Inicia os comportamentos registrados, em ordem de nome para que a partida
+// seja reproduzível
+func iniciarComportamentos(ctx context.Context, jogo *Jogo) {
+	nomes := make([]string, 0, len(comportamentos))
+	for nome := range comportamentos {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	for _, nome := range nomes {
+		comportamentos[nome](ctx, jogo)
+	}
+}
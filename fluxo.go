@@ -0,0 +1,141 @@
+// fluxo.go - Mapas de distância (Dijkstra) compartilhados por muitos perseguidores
+package main
+
+import "container/heap"
+
+// Valor usado para células que não alcançam o alvo
+const distanciaInfinita = 1 << 30
+
+// MapaFluxo guarda, para cada célula, o custo do caminho até o alvo. Qualquer
+// número de entidades pode segui-lo descendo para a vizinha de menor valor,
+// sem que cada uma precise fazer a sua própria busca.
+type MapaFluxo struct {
+	Alvo Ponto
+	dist [][]int
+}
+
+// Custo de entrar em uma célula para o cálculo do fluxo. Só o terreno fixo é
+// considerado, para que o mapa só precise ser refeito quando o alvo se move;
+// células ocupadas por outras entidades são verificadas na hora do passo.
+func custoFluxo(e Elemento) (int, bool) {
+	switch e.simbolo {
+	case Parede.simbolo:
+		return 0, false
+	case Vegetacao.simbolo:
+		return 2, true
+	}
+	return 1, true
+}
+
+// Calcula com Dijkstra a distância de todas as células até o alvo
+func fluxoCalcular(mapa [][]Elemento, alvo Ponto) *MapaFluxo {
+	f := &MapaFluxo{Alvo: alvo, dist: fluxoVazio(mapa)}
+	if !dentroDoMapa(mapa, alvo) {
+		return f
+	}
+	f.dist[alvo.Y][alvo.X] = 0
+	fluxoRelaxar(mapa, f.dist, []Ponto{alvo})
+	return f
+}
+
+// Cria o mapa de fuga a partir de um mapa de fluxo. As distâncias são
+// invertidas e multiplicadas por 1,2 antes de serem propagadas de novo, de
+// modo que quem desce este mapa se afasta do alvo, mas prefere contorná-lo a
+// ficar encurralado num beco sem saída.
+func fluxoFuga(mapa [][]Elemento, perseguicao *MapaFluxo) *MapaFluxo {
+	f := &MapaFluxo{Alvo: perseguicao.Alvo, dist: fluxoVazio(mapa)}
+	var origens []Ponto
+	for y, linha := range perseguicao.dist {
+		for x, d := range linha {
+			if d < distanciaInfinita {
+				f.dist[y][x] = -d * 12 / 10
+				origens = append(origens, Ponto{x, y})
+			}
+		}
+	}
+	fluxoRelaxar(mapa, f.dist, origens)
+	return f
+}
+
+// Distância da célula até o alvo (distanciaInfinita se inalcançável)
+func (f *MapaFluxo) Distancia(p Ponto) int {
+	if p.Y < 0 || p.Y >= len(f.dist) || p.X < 0 || p.X >= len(f.dist[p.Y]) {
+		return distanciaInfinita
+	}
+	return f.dist[p.Y][p.X]
+}
+
+// Escolhe, entre as vizinhas livres, a de menor distância, se ela for melhor
+// que a célula atual
+func (f *MapaFluxo) ProximoPasso(origem Ponto, livre func(p Ponto) bool) (Ponto, bool) {
+	melhor, melhorDist := origem, f.Distancia(origem)
+	for _, d := range vizinhosRetos {
+		p := Ponto{origem.X + d.X, origem.Y + d.Y}
+		if dist := f.Distancia(p); dist < melhorDist && livre(p) {
+			melhor, melhorDist = p, dist
+		}
+	}
+	return melhor, melhor != origem
+}
+
+// Cria a matriz de distâncias com todas as células inalcançáveis
+func fluxoVazio(mapa [][]Elemento) [][]int {
+	dist := make([][]int, len(mapa))
+	for y := range mapa {
+		dist[y] = make([]int, len(mapa[y]))
+		for x := range dist[y] {
+			dist[y][x] = distanciaInfinita
+		}
+	}
+	return dist
+}
+
+// Propaga as distâncias a partir das origens (Dijkstra com várias origens)
+func fluxoRelaxar(mapa [][]Elemento, dist [][]int, origens []Ponto) {
+	abertos := &filaPrioridade{}
+	for _, p := range origens {
+		heap.Push(abertos, noBusca{p, dist[p.Y][p.X]})
+	}
+	for abertos.Len() > 0 {
+		no := heap.Pop(abertos).(noBusca)
+		atual := no.ponto
+		if no.f > dist[atual.Y][atual.X] {
+			continue // entrada antiga, a célula já foi melhorada
+		}
+		for _, d := range vizinhosRetos {
+			p := Ponto{atual.X + d.X, atual.Y + d.Y}
+			if !dentroDoMapa(mapa, p) {
+				continue
+			}
+			custo, ok := custoFluxo(mapa[p.Y][p.X])
+			if !ok {
+				continue
+			}
+			if novo := no.f + custo; novo < dist[p.Y][p.X] {
+				dist[p.Y][p.X] = novo
+				heap.Push(abertos, noBusca{p, novo})
+			}
+		}
+	}
+}
+
+// Mapa de fluxo em direção ao jogador, refeito só quando o jogador muda de
+// posição (chamada pelo dono do jogo)
+func jogoFluxoPerseguicao(jogo *Jogo) *MapaFluxo {
+	jogador := Ponto{jogo.PosX, jogo.PosY}
+	if jogo.fluxo == nil || jogo.fluxo.Alvo != jogador {
+		jogo.fluxo = fluxoCalcular(jogo.Mapa, jogador)
+		jogo.fluxoFuga = nil
+	}
+	return jogo.fluxo
+}
+
+// Mapa de fuga do jogador, derivado do mapa de perseguição atual (chamada
+// pelo dono do jogo)
+func jogoFluxoFuga(jogo *Jogo) *MapaFluxo {
+	perseguicao := jogoFluxoPerseguicao(jogo)
+	if jogo.fluxoFuga == nil {
+		jogo.fluxoFuga = fluxoFuga(jogo.Mapa, perseguicao)
+	}
+	return jogo.fluxoFuga
+}
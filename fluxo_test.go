@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+func TestFluxoDistancia(t *testing.T) {
+	mapa := mapaTeste(
+		`  "  `,
+		` ### `,
+		`   ##`,
+		`## # `,
+	)
+	fluxo := fluxoCalcular(mapa, Ponto{0, 0})
+	casos := []struct {
+		nome string
+		p    Ponto
+		dist int
+	}{
+		{"o próprio alvo", Ponto{0, 0}, 0},
+		{"vizinha", Ponto{1, 0}, 1},
+		{"vegetação custa dois", Ponto{2, 0}, 3},
+		{"depois da vegetação", Ponto{4, 0}, 5},
+		{"contornando a parede", Ponto{2, 2}, 4},
+		{"parede", Ponto{2, 1}, distanciaInfinita},
+		{"célula isolada", Ponto{4, 3}, distanciaInfinita},
+		{"fora do mapa", Ponto{-1, 0}, distanciaInfinita},
+		{"além do fim da linha", Ponto{5, 2}, distanciaInfinita},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if d := fluxo.Distancia(c.p); d != c.dist {
+				t.Fatalf("distância de %v: %d, esperava %d", c.p, d, c.dist)
+			}
+		})
+	}
+}
+
+func TestFluxoAlvoForaDoMapa(t *testing.T) {
+	mapa := mapaTeste("   ")
+	fluxo := fluxoCalcular(mapa, Ponto{7, 7})
+	for x := range 3 {
+		if d := fluxo.Distancia(Ponto{x, 0}); d != distanciaInfinita {
+			t.Fatalf("distância de %d,0: %d, esperava inalcançável", x, d)
+		}
+	}
+}
+
+func TestFluxoProximoPasso(t *testing.T) {
+	mapa := mapaTeste(
+		"     ",
+		"     ",
+	)
+	corredor := mapaTeste("     ")
+	alvo := Ponto{4, 0}
+	livre := func(p Ponto) bool { return true }
+	casos := []struct {
+		nome   string
+		fluxo  *MapaFluxo
+		origem Ponto
+		livre  func(p Ponto) bool
+		passo  Ponto
+		anda   bool
+	}{
+		{"desce em direção ao alvo", fluxoCalcular(mapa, alvo), Ponto{0, 0}, livre, Ponto{1, 0}, true},
+		{"desvia da vizinha ocupada", fluxoCalcular(mapa, alvo), Ponto{0, 1},
+			func(p Ponto) bool { return p != Ponto{0, 0} }, Ponto{1, 1}, true},
+		{"espera se a única melhor está ocupada", fluxoCalcular(mapa, alvo), Ponto{0, 0},
+			func(p Ponto) bool { return p != Ponto{1, 0} }, Ponto{0, 0}, false},
+		{"fica parado no alvo", fluxoCalcular(mapa, alvo), alvo, livre, alvo, false},
+		{"foge do alvo", fluxoFuga(corredor, fluxoCalcular(corredor, alvo)), Ponto{3, 0}, livre, Ponto{2, 0}, true},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			passo, anda := c.fluxo.ProximoPasso(c.origem, c.livre)
+			if passo != c.passo || anda != c.anda {
+				t.Fatalf("próximo passo a partir de %v: %v, %v; esperava %v, %v", c.origem, passo, anda, c.passo, c.anda)
+			}
+		})
+	}
+}
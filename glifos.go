@@ -0,0 +1,201 @@
+// glifos.go - Conjuntos de glifos usados para desenhar e ler os mapas
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// ConjuntoGlifos diz com que caractere cada elemento aparece na tela
+type ConjuntoGlifos struct {
+	Nome    string
+	glifos  map[string]rune // chave do elemento -> glifo
+	Largura int             // colunas de cada célula do mapa (2 se algum glifo é largo)
+}
+
+// Formato de um arquivo de glifos: um conjunto pronto como base e os glifos
+// que mudam em relação a ele
+type arquivoGlifos struct {
+	Base   string            `json:"base"`
+	Glifos map[string]string `json:"glifos"`
+}
+
+// Diretório onde conjuntos de glifos são procurados pelo nome
+const diretorioGlifos = "glifos"
+
+// Conjuntos prontos. O unicode usa os próprios símbolos dos elementos; o
+// ascii funciona em qualquer terminal e fonte. O ascii evita letras nos
+// elementos lidos dos mapas, pois os mapas podem ter textos escritos.
+var conjuntosProntos = map[string]map[string]string{
+	"unicode": {
+		"personagem": "☺", "inimigo": "☠", "parede": "▤", "vegetacao": "♣", "vazio": " ",
+		"portal": "O", "armadilha": "X", "fantasma": "G", "tesouro": "$", "guardiao": "@", "perseguidor": "&",
+		"laco": "∞", "alarme": "♪", "teleporte": "◎", "armadilha_gasta": "·",
+		"armadilha_jogador": "⊗", "isca": "¤",
+	},
+	"ascii": {
+		"personagem": "@", "inimigo": "!", "parede": "#", "vegetacao": "\"", "vazio": " ",
+		"portal": "O", "armadilha": "^", "fantasma": "G", "tesouro": "$", "guardiao": "W", "perseguidor": "&",
+		"laco": "%", "alarme": "*", "teleporte": "~", "armadilha_gasta": ".",
+		"armadilha_jogador": "+", "isca": "?",
+	},
+}
+
+// Elementos que podem ser desenhados no arquivo do mapa; os demais surgem
+// durante a partida
+var elementosDoMapa = map[string]Elemento{
+	"personagem":  Personagem,
+	"inimigo":     Inimigo,
+	"parede":      Parede,
+	"vegetacao":   Vegetacao,
+	"perseguidor": Perseguidor,
+}
+
+// Escolhe o conjunto pelo nome. Em "auto" usa o unicode se o terminal usa
+// UTF-8 (pelas variáveis LC_ALL, LC_CTYPE e LANG) e o ascii nos demais casos.
+func glifosCarregar(nome string) (*ConjuntoGlifos, error) {
+	if nome == "auto" {
+		nome = "ascii"
+		for _, v := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+			if valor := os.Getenv(v); valor != "" {
+				if codificacao := strings.ToLower(valor); strings.Contains(codificacao, "utf-8") || strings.Contains(codificacao, "utf8") {
+					nome = "unicode"
+				}
+				break
+			}
+		}
+	}
+
+	arq := arquivoGlifos{Base: nome}
+	if _, ok := conjuntosProntos[nome]; !ok {
+		caminho := nome
+		if !strings.HasSuffix(nome, ".json") {
+			caminho = filepath.Join(diretorioGlifos, nome+".json")
+		}
+		dados, err := os.ReadFile(caminho)
+		if err != nil {
+			return nil, erroTraduzido("erro.glifos", nome, strings.Join(glifosDisponiveis(), ", "))
+		}
+		arq = arquivoGlifos{}
+		if err := configDecodificar(dados, &arq); err != nil {
+			return nil, fmt.Errorf("%s: %v", caminho, err)
+		}
+		if arq.Base == "" {
+			arq.Base = "unicode"
+		}
+	}
+	base, ok := conjuntosProntos[arq.Base]
+	if !ok {
+		return nil, erroTraduzido("erro.glifos_base", nome, arq.Base)
+	}
+
+	c := &ConjuntoGlifos{Nome: nome, glifos: make(map[string]rune), Largura: 1}
+	usados := make(map[rune]string)
+	for _, chave := range chavesOrdenadas(base, arq.Glifos) {
+		texto, ok := arq.Glifos[chave]
+		if !ok {
+			texto = base[chave]
+		}
+		if !chaveElementoValida(chave) {
+			return nil, erroTraduzido("erro.glifos_elemento", nome, chave)
+		}
+		runas := []rune(texto)
+		if len(runas) != 1 {
+			return nil, erroTraduzido("erro.glifos_caractere", nome, chave, texto)
+		}
+		g := runas[0]
+		largura := runewidth.RuneWidth(g)
+		switch {
+		case unicode.IsDigit(g):
+			return nil, erroTraduzido("erro.glifos_digito", nome, chave)
+		case largura < 1 || largura > 2:
+			return nil, erroTraduzido("erro.glifos_largura", nome, chave, texto)
+		}
+		if outra, repetido := usados[g]; repetido {
+			return nil, erroTraduzido("erro.glifos_repetido", nome, texto, outra, chave)
+		}
+		usados[g] = chave
+		c.glifos[chave] = g
+		c.Largura = max(c.Largura, largura)
+	}
+	return c, nil
+}
+
+// Chaves dos dois mapas, sem repetição e em ordem alfabética
+func chavesOrdenadas(a, b map[string]string) []string {
+	var chaves []string
+	for chave := range a {
+		chaves = append(chaves, chave)
+	}
+	for chave := range b {
+		if _, ok := a[chave]; !ok {
+			chaves = append(chaves, chave)
+		}
+	}
+	sort.Strings(chaves)
+	return chaves
+}
+
+// Nomes dos conjuntos prontos, em ordem alfabética
+func glifosDisponiveis() []string {
+	nomes := make([]string, 0, len(conjuntosProntos))
+	for nome := range conjuntosProntos {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	return nomes
+}
+
+// Glifo com que o elemento aparece na tela
+func (c *ConjuntoGlifos) Glifo(e Elemento) rune {
+	if g, ok := c.glifos[chavesElemento[e.simbolo]]; ok {
+		return g
+	}
+	return e.simbolo
+}
+
+// Indica se todos os glifos são ASCII; nesse caso o desenho evita os
+// caracteres de bloco
+func (c *ConjuntoGlifos) SoASCII() bool {
+	for _, g := range c.glifos {
+		if g >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// Tabela usada para ler mapas: o glifo de cada elemento que pode estar no
+// arquivo, em qualquer dos conjuntos prontos ou no conjunto indicado. Quando
+// dois conjuntos usam o mesmo glifo vale o indicado e depois o unicode.
+func glifosLeitura(c *ConjuntoGlifos) map[rune]Elemento {
+	tabela := make(map[rune]Elemento)
+	acrescentar := func(glifos map[string]rune) {
+		for chave, g := range glifos {
+			if e, ok := elementosDoMapa[chave]; ok {
+				if _, ja := tabela[g]; !ja {
+					tabela[g] = e
+				}
+			}
+		}
+	}
+	if c != nil {
+		acrescentar(c.glifos)
+	}
+	for _, nome := range glifosDisponiveis() {
+		if nome == "ascii" {
+			continue // o ascii vem por último
+		}
+		pronto, _ := glifosCarregar(nome)
+		acrescentar(pronto.glifos)
+	}
+	ascii, _ := glifosCarregar("ascii")
+	acrescentar(ascii.glifos)
+	return tabela
+}
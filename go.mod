@@ -3,7 +3,8 @@ module jogo
 go 1.25.0
 
 require (
-	github.com/mattn/go-runewidth v0.0.16 // indirect
-	github.com/nsf/termbox-go v1.1.1 // indirect
-	github.com/rivo/uniseg v0.4.7 // indirect
+	github.com/mattn/go-runewidth v0.0.16
+	github.com/nsf/termbox-go v1.1.1
 )
+
+require github.com/rivo/uniseg v0.4.7 // indirect
@@ -0,0 +1,117 @@
+// idioma.go - Tradução dos textos exibidos ao jogador
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Catalogo associa o identificador de cada texto à sua tradução. Os textos
+// usam os verbos do fmt (%d, %s) para os valores que variam.
+type Catalogo map[string]string
+
+// Idioma usado quando nenhum é escolhido; também é a referência quando um
+// texto falta em outro catálogo
+const idiomaPadrao = "pt-BR"
+
+// Variável de ambiente consultada quando a opção -idioma não é usada
+const variavelIdioma = "JOGO_IDIOMA"
+
+// Catálogos disponíveis, preenchidos pelos arquivos idioma_*.go
+var catalogos = map[string]Catalogo{}
+
+// Catálogo em uso
+var idiomaAtual = idiomaPadrao
+
+// Escolhe o idioma pela opção da linha de comando ou, sem ela, pela variável
+// JOGO_IDIOMA. Aceita variações como "en_US.UTF-8" ou "pt".
+func idiomaEscolher(opcao string) error {
+	nome := opcao
+	if nome == "" {
+		nome = os.Getenv(variavelIdioma)
+	}
+	if nome == "" {
+		nome = idiomaPadrao
+	}
+	normalizado, ok := idiomaNormalizar(nome)
+	if !ok {
+		return erroTraduzido("erro.idioma", nome, strings.Join(idiomasDisponiveis(), ", "))
+	}
+	idiomaAtual = normalizado
+	return nil
+}
+
+// Escolhe o idioma antes de interpretar a linha de comando, para que a ajuda
+// das opções já saia traduzida. Um idioma inválido é ignorado aqui e
+// relatado depois, quando idiomaEscolher recebe o valor de -idioma.
+func idiomaAntecipar(args []string) {
+	opcao := ""
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		nome, valor, temValor := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || nome != "idioma" {
+			continue
+		}
+		if !temValor && i+1 < len(args) {
+			valor = args[i+1]
+		}
+		opcao = valor
+	}
+	idiomaEscolher(opcao)
+}
+
+// Encontra o catálogo correspondente a um nome de idioma
+func idiomaNormalizar(nome string) (string, bool) {
+	nome = strings.SplitN(nome, ".", 2)[0] // descarta a codificação ("UTF-8")
+	nome = strings.ReplaceAll(nome, "_", "-")
+	for disponivel := range catalogos {
+		if strings.EqualFold(nome, disponivel) {
+			return disponivel, true
+		}
+	}
+	// Só o idioma, sem a região ("pt", "en-US")
+	base := strings.SplitN(nome, "-", 2)[0]
+	for _, disponivel := range idiomasDisponiveis() {
+		if strings.EqualFold(base, strings.SplitN(disponivel, "-", 2)[0]) {
+			return disponivel, true
+		}
+	}
+	return "", false
+}
+
+// Nomes dos idiomas disponíveis, em ordem alfabética
+func idiomasDisponiveis() []string {
+	nomes := make([]string, 0, len(catalogos))
+	for nome := range catalogos {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	return nomes
+}
+
+// Traduz um texto para o idioma em uso, preenchendo os valores indicados.
+// Textos ausentes do catálogo usam o idioma padrão e, em último caso, o
+// próprio identificador.
+func traduzir(id string, valores ...any) string {
+	formato, ok := catalogos[idiomaAtual][id]
+	if !ok {
+		formato, ok = catalogos[idiomaPadrao][id]
+	}
+	if !ok {
+		formato = id
+	}
+	if len(valores) == 0 {
+		return formato
+	}
+	return fmt.Sprintf(formato, valores...)
+}
+
+// Cria um erro com o texto traduzido para o idioma em uso
+func erroTraduzido(id string, valores ...any) error {
+	return errors.New(traduzir(id, valores...))
+}
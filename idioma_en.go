@@ -0,0 +1,294 @@
+// idioma_en.go - Textos em inglês
+package main
+
+func init() {
+	catalogos["en"] = Catalogo{
+		"portal.usado":    "Portal used! Teleporting!",
+		"portal.apareceu": "A portal appeared!",
+		"portal.fechou":   "The portal closed on its own",
+
+		"fantasma.pegou": "The ghost got you!",
+
+		"armadilha.disparou":  "A trap went off under your feet!",
+		"armadilha.ativada":   "Trap armed!",
+		"armadilha.desarmada": "Trap disarmed",
+		"armadilha.recolhida": "You pick up your trap; you now carry %d",
+		"armadilha.expirou":   "A trap expired",
+		"armadilha.espinhos":  "Spikes shoot up from the floor!",
+		"armadilha.laco":      "A snare catches your feet!",
+		"armadilha.alarme":    "An alarm rings! The guardian knows where you are",
+		"armadilha.teleporte": "The floor glows and whisks you away!",
+		"armadilha.atordoou":  "Your trap caught %s!",
+		"armadilha.colocada":  "Trap placed",
+		"armadilha.recusada":  "You can't place the trap there",
+		"armadilha.escondida": "A hidden trap was there, and it went off!",
+
+		"isca.colocada": "Lure placed",
+		"isca.recusada": "You can't place the lure there",
+
+		"tesouro.apareceu": "Treasure appeared!",
+		"tesouro.coletado": "Treasure collected!",
+
+		"guardiao.despertou":          "The guardian woke up!",
+		"guardiao.alarme":             "Alarm! The guardian is after you!",
+		"guardiao.acordou":            "You woke the guardian!",
+		"guardiao.detectou":           "The guardian spotted you!",
+		"guardiao.desistiu":           "The guardian gave up searching",
+		"guardiao.perdeu":             "The guardian lost sight of you",
+		"guardiao.cansou":             "The guardian got tired of chasing you",
+		"guardiao.golpe":              "The guardian hit you!",
+		"guardiao.viu":                "The guardian saw you again!",
+		"guardiao.adormeceu":          "The guardian fell asleep",
+		"guardiao.estado.dormindo":    "asleep",
+		"guardiao.estado.desconfiado": "suspicious",
+		"guardiao.estado.perseguindo": "chasing",
+		"guardiao.estado.atacando":    "attacking",
+		"guardiao.estado.retornando":  "returning",
+
+		"enxame.alcancou": "The swarm caught you!",
+
+		"jogo.fim":      "Game over! Press %s to quit.",
+		"jogo.pausado":  "Game paused. Press %s to continue.",
+		"jogo.retomado": "Game resumed",
+
+		"jogador.armadilha":         "You stepped on a trap! Careful!",
+		"jogador.fantasma":          "You walked through the ghost... creepy!",
+		"jogador.parede":            "You bumped into the wall!",
+		"jogador.bloqueio_inimigo":  "An enemy is blocking the way!",
+		"jogador.bloqueio_guardiao": "The guardian won't let you through!",
+		"jogador.bloqueado":         "The way is blocked!",
+		"jogador.preso":             "You are caught in the snare!",
+
+		"caminhada.aqui":          "You are already here",
+		"caminhada.intransitavel": "You can't walk to (%d, %d)",
+		"caminhada.sem_caminho":   "There is no path to (%d, %d)",
+		"caminhada.iniciada":      "Walking to (%d, %d)...",
+
+		"inspecao.celula":            "(%d, %d): %s",
+		"inspecao.vazio":             "open floor",
+		"inspecao.parede":            "a solid wall",
+		"inspecao.vegetacao":         "vegetation, you can walk through it",
+		"inspecao.inimigo":           "a patrolling enemy",
+		"inspecao.perseguidor":       "a swarm chaser",
+		"inspecao.portal":            "an open portal",
+		"inspecao.armadilha":         "a spike trap",
+		"inspecao.laco":              "an armed snare",
+		"inspecao.alarme":            "an alarm trap",
+		"inspecao.teleporte":         "a teleport trap",
+		"inspecao.armadilha_gasta":   "a sprung trap, not yet re-armed",
+		"inspecao.armadilha_jogador": "one of your traps",
+		"inspecao.isca":              "a lure",
+		"inspecao.fantasma":          "a ghost",
+		"inspecao.tesouro":           "a treasure",
+		"inspecao.voce":              "you",
+		"inspecao.guardiao":          "the guardian (%s)",
+		"inspecao.desconhecido":      "something unknown",
+
+		"interacao.portal":                 "Using the portal...",
+		"interacao.tesouro":                "Collecting treasure!",
+		"interacao.vegetacao":              "You look at the vegetation... nothing interesting",
+		"interacao.parede":                 "You touch the wall... it is solid",
+		"interacao.inimigo":                "The enemy glares at you!",
+		"interacao.guardiao_dormindo":      "The guardian sleeps... for now",
+		"interacao.guardiao_acordado":      "The guardian stares at you, ready to strike!",
+		"interacao.nada":                   "Interacting at (%d, %d) - nothing happens",
+		"interacao.armadilha":              "You carefully disarm the trap...",
+		"interacao.armadilhas_encontradas": "You search around and find %d hidden trap(s)!",
+
+		"acao.sair":          "Leaving the game...",
+		"acao.esperar":       "You wait a moment...",
+		"acao.inventario":    "Inventory: %d treasure(s), %d point(s), %d life(s), %d trap(s), %d lure(s)",
+		"acao.salvo":         "Match saved to %s",
+		"acao.salvar_falhou": "Could not save the match: %v",
+
+		"colocar.sem_espaco":     "There is no free tile next to you",
+		"colocar.sem_armadilhas": "You have no traps left",
+		"colocar.sem_iscas":      "You have no lures left",
+
+		"hud.placar": "Lives: %d  Points: %d  Treasures: %d  Guardian: %s",
+		"hud.alerta": " [ALERT!]",
+
+		"historico.titulo": "Message history (%d-%d of %d)",
+		"historico.ajuda":  "%s/%s scroll | %s or %s close",
+
+		"ajuda.mover":      "move",
+		"ajuda.interagir":  "interact",
+		"ajuda.sair":       "quit",
+		"ajuda.pausar":     "pause",
+		"ajuda.esperar":    "wait",
+		"ajuda.armar":      "trap",
+		"ajuda.isca":       "lure",
+		"ajuda.inventario": "inventory",
+		"ajuda.mensagens":  "messages",
+		"ajuda.minimapa":   "minimap",
+		"ajuda.salvar":     "save",
+
+		"tecla.espaco": "Space",
+		"tecla.seta":   "arrow",
+		"tecla.setas":  "arrows",
+
+		"minimapa.titulo":  "Map",
+		"minimapa.exibido": "Minimap shown",
+		"minimapa.oculto":  "Minimap hidden",
+
+		"armadilha.nome.espinhos":  "spikes",
+		"armadilha.nome.laco":      "snare",
+		"armadilha.nome.alarme":    "alarm",
+		"armadilha.nome.teleporte": "teleport",
+
+		"estado.atordoado":          "stunned",
+		"estado.patrulhando":        "patrolling",
+		"estado.esperando_livre":    "waiting for the start point to clear",
+		"estado.atraido":            "lured to %d,%d",
+		"estado.parado_ponto":       "stopped at point %d",
+		"estado.indo_ponto":         "heading to point %d",
+		"estado.portal_fechado":     "closed",
+		"estado.portal_usado":       "closed (used)",
+		"estado.portal_aberto":      "open",
+		"estado.portal_esgotado":    "closed (timed out)",
+		"estado.perseguindo":        "chasing",
+		"estado.vagando":            "wandering",
+		"estado.aguardando":         "waiting for requests",
+		"estado.isca_colocada":      "lure placed",
+		"estado.isca_sumiu":         "lure gone",
+		"estado.armadilha_jogador":  "player trap",
+		"estado.armou":              "armed %s",
+		"estado.desarmou":           "disarmed",
+		"estado.expirou":            "expired",
+		"estado.atordoou":           "stunned %s",
+		"estado.rearmou":            "re-armed %s",
+		"estado.disparou":           "sprang %s",
+		"estado.encontrada":         "found %s",
+		"estado.surgiu":             "appeared",
+		"estado.coletado":           "collected",
+		"estado.guardiao":           "%s%s%s",
+		"estado.sufixo_alerta":      " (alert)",
+		"estado.sufixo_atordoado":   " (stunned)",
+		"estado.enxame_perseguindo": "%d chasing",
+		"estado.enxame_atordoados":  "%d chasing, %d stunned",
+		"estado.enxame_dispersando": "%d scattering for %d pulses",
+		"estado.controle_area":      "player in the guardian area for %d pulses",
+		"estado.caminhada_faltam":   "%d steps left",
+		"estado.caminhada_parada":   "stopped",
+
+		"depuracao.exibida": "Debug panel shown",
+		"depuracao.oculta":  "Debug panel hidden",
+		"depuracao.titulo":  "Debug  goroutines: %d  time: %v",
+		"depuracao.posicao": " at %d,%d",
+		"depuracao.filas":   "Queues (pending/capacity):",
+		"depuracao.fila":    " %-12s %d/%s  delivered %v  dropped %d",
+		"depuracao.quadros": "Frames: %d drawn, %d requests merged, %d cells in the last",
+		"depuracao.relogio": "Clock: step %v, %d steps, %d dropped for lag",
+		"depuracao.acesso":  "Intents: %d applied, %d queued",
+		"depuracao.espera":  " wait avg %v  last %v  max %v",
+
+		"narracao.inicio":       "Narration mode, map %s. Type one command per line; ? lists the commands.",
+		"narracao.ajuda":        "Commands: w, a, s, d or north, west, south, east to walk; e interact; f trap; r lure; empty line wait; l describe surroundings; i inventory; m recent messages; save (the match); q quit.",
+		"narracao.desconhecido": "Unknown command: %q. Type ? to list the commands.",
+		"narracao.perigo":       "Danger! %s",
+		"narracao.fim":          "Game over. You finished with %d points.",
+		"narracao.posicao":      "You are at (%d, %d). Lives: %d. Score: %d. Treasures: %d.",
+		"narracao.sob":          "Under you: %s.",
+		"narracao.direcao":      "%s: %s.",
+		"narracao.norte":        "North",
+		"narracao.sul":          "South",
+		"narracao.oeste":        "West",
+		"narracao.leste":        "East",
+		"narracao.limite":       "edge of the map",
+		"narracao.tesouro":      "Nearest treasure: %s.",
+		"narracao.sem_tesouro":  "No treasure on the map.",
+		"narracao.ameacas":      "Threats: %s.",
+		"narracao.ameaca":       "%s, %s",
+		"narracao.sem_ameacas":  "No threats nearby.",
+		"narracao.rumo.norte":   "%d north",
+		"narracao.rumo.sul":     "%d south",
+		"narracao.rumo.oeste":   "%d west",
+		"narracao.rumo.leste":   "%d east",
+		"narracao.rumo.e":       " and ",
+		"narracao.rumo.aqui":    "right here",
+
+		"simulacao.resumo":  "map: %s  agent: %s  games: %d  seeds: %d-%d  steps: %d",
+		"simulacao.mortes":  "games that ran out of lives: %d (%.1f%%)",
+		"simulacao.colunas": "metric\tmin\tmean\tp50\tp90\tmax\t",
+
+		"erro.agente":              "unknown agent: %s",
+		"erro.duracao":             "duration must be a string such as \"800ms\": %s",
+		"erro.positivo":            "%s must be positive",
+		"erro.minimo":              "%s must be at least %d",
+		"erro.glifos":              "unknown glyph set: %q (built in: %s)",
+		"erro.glifos_base":         "glyphs %s: unknown base: %q",
+		"erro.glifos_elemento":     "glyphs %s: unknown element: %q",
+		"erro.glifos_caractere":    "glyphs %s: %s: the glyph must be a single character: %q",
+		"erro.glifos_digito":       "glyphs %s: %s: digits are reserved for route markers",
+		"erro.glifos_largura":      "glyphs %s: %s: %q is not one or two columns wide",
+		"erro.glifos_repetido":     "glyphs %s: %q used by both %s and %s",
+		"erro.modo_cores":          "unknown color mode: %q (use auto, basicas, 256 or rgb)",
+		"erro.tema":                "unknown theme: %q (built in: %s)",
+		"erro.tema_base":           "theme %s: unknown base: %q",
+		"erro.tema_elemento":       "theme %s: unknown element: %q",
+		"erro.tema_estilo":         "theme %s: %s: %v",
+		"erro.atributo":            "unknown attribute: %q",
+		"erro.cor_indice":          "color index outside the 256-color palette: %d",
+		"erro.cor":                 "unknown color: %q",
+		"erro.layout":              "unknown keyboard layout: %q",
+		"erro.atalho":              "unknown action in the key bindings: %q",
+		"erro.tecla_repetida":      "key %q bound to both %s and %s",
+		"erro.sair_sem_tecla":      "the quit action needs at least one key",
+		"erro.tecla":               "unknown key: %q",
+		"erro.simulacao_positivos": "-n, -paralelo and -passos must be positive",
+		"erro.formato":             "unknown format: %s",
+		"erro.dt":                  "-dt must be positive",
+		"erro.idioma":              "unknown language: %q (available: %s)",
+		"erro.rota_diretiva":       "unknown directive: %q",
+		"erro.rota_ponto":          "invalid point in route %s: %q",
+		"erro.rota_modo":           "unknown mode in route %s: %q",
+		"erro.rota_positivo":       "must be positive",
+		"erro.rota_opcao":          "unknown option in route %s: %q",
+		"erro.rota_valor":          "invalid value for %s in route %s: %v",
+		"erro.rota_vazia":          "route %s has no points",
+		"erro.rota_fora":           "point %d,%d of route %s is off the map or blocked",
+		"erro.diario_tamanho":      "session log: the maximum size must be positive",
+		"erro.diario_copias":       "session log: the number of copies cannot be negative",
+		"erro.diario":              "session log: %v",
+
+		"erro.salvamento":           "%s is not a saved match: %v",
+		"erro.salvamento_versao":    "%s was saved with version %d of the format; this game reads version %d",
+		"erro.salvamento_elemento":  "saved match: unknown element %q",
+		"erro.salvamento_armadilha": "saved match: unknown trap type %q",
+		"erro.salvamento_jogador":   "saved match: the character at %d,%d is off the map",
+		"erro.salvamento_posicao":   "saved match: position %d,%d is off the map",
+
+		"ajuda.bot":              "controls the character with an agent (aleatorio, guloso)",
+		"ajuda.intervalo_bot":    "time between the agent's actions",
+		"ajuda.config":           "configuration file (default: config.json, if present)",
+		"ajuda.idioma":           "text language: pt-BR or en (default: the JOGO_IDIOMA variable or pt-BR)",
+		"ajuda.log":              "writes the match events to this file, as JSON, one per line",
+		"ajuda.log_tamanho":      "maximum size of the -log file, in MB, before it is rotated",
+		"ajuda.log_copias":       "how many rotated -log files are kept",
+		"ajuda.carregar":         "continues the match written to this file by the save action",
+		"ajuda.narrar_dt":        "game time that passes with each action",
+		"ajuda.narrar_semente":   "seed for the random elements (default: the current time)",
+		"ajuda.simular_bot":      "agent that controls the character (aleatorio, guloso)",
+		"ajuda.simular_n":        "number of games",
+		"ajuda.simular_semente":  "first seed; the games use consecutive seeds",
+		"ajuda.simular_passos":   "maximum steps per game",
+		"ajuda.simular_paralelo": "games run at the same time",
+		"ajuda.simular_dt":       "game time of each step",
+		"ajuda.simular_formato":  "output format: tabela (summary) or csv (one line per game)",
+
+		"falha.jogo":           "the game crashed (%s): %s",
+		"falha.relatorio":      "crash report written to %s",
+		"falha.erro_relatorio": "could not write the crash report: %v",
+		"falha.titulo":         "The game crashed at %s",
+		"falha.goroutine":      "Goroutine: %s",
+		"falha.panico":         "Panic: %s",
+		"falha.pendentes":      "Goroutines that did not finish: %s",
+		"falha.sem_estado":     "The match state was not copied, since they could still change it.",
+		"falha.estado":         "Match state:",
+		"falha.jogador":        "Player at %d,%d  lives %d  points %d",
+		"falha.estatisticas":   "Statistics: %+v",
+		"falha.semente":        "Seed: %d",
+		"falha.mensagens":      "Messages:",
+		"falha.mapa":           "Map:",
+	}
+}
@@ -0,0 +1,294 @@
+// idioma_ptbr.go - Textos em português do Brasil, o idioma padrão
+package main
+
+func init() {
+	catalogos["pt-BR"] = Catalogo{
+		"portal.usado":    "Portal usado! Teletransporte!",
+		"portal.apareceu": "Portal apareceu!",
+		"portal.fechou":   "Portal fechou automaticamente",
+
+		"fantasma.pegou": "O fantasma te pegou!",
+
+		"armadilha.disparou":  "Uma armadilha disparou sob seus pés!",
+		"armadilha.ativada":   "Armadilha ativada!",
+		"armadilha.desarmada": "Armadilha desarmada",
+		"armadilha.recolhida": "Você recolhe sua armadilha; agora tem %d",
+		"armadilha.expirou":   "Armadilha expirou",
+		"armadilha.espinhos":  "Espinhos saltam do chão!",
+		"armadilha.laco":      "Um laço prende seus pés!",
+		"armadilha.alarme":    "Um alarme soa! O guardião sabe onde você está",
+		"armadilha.teleporte": "O chão brilha e leva você para longe!",
+		"armadilha.atordoou":  "Sua armadilha pegou %s!",
+		"armadilha.colocada":  "Armadilha colocada",
+		"armadilha.recusada":  "Não dá para colocar a armadilha ali",
+		"armadilha.escondida": "Havia uma armadilha escondida ali, e ela disparou!",
+
+		"isca.colocada": "Isca colocada",
+		"isca.recusada": "Não dá para colocar a isca ali",
+
+		"tesouro.apareceu": "Tesouro apareceu!",
+		"tesouro.coletado": "Tesouro coletado!",
+
+		"guardiao.despertou":          "Guardião despertou!",
+		"guardiao.alarme":             "Alarme! O guardião está atrás de você!",
+		"guardiao.acordou":            "Você acordou o guardião!",
+		"guardiao.detectou":           "Guardião te detectou!",
+		"guardiao.desistiu":           "O guardião desistiu de procurar",
+		"guardiao.perdeu":             "O guardião te perdeu de vista",
+		"guardiao.cansou":             "O guardião cansou de te perseguir",
+		"guardiao.golpe":              "O guardião te golpeou!",
+		"guardiao.viu":                "O guardião te viu de novo!",
+		"guardiao.adormeceu":          "Guardião adormeceu",
+		"guardiao.estado.dormindo":    "dormindo",
+		"guardiao.estado.desconfiado": "desconfiado",
+		"guardiao.estado.perseguindo": "perseguindo",
+		"guardiao.estado.atacando":    "atacando",
+		"guardiao.estado.retornando":  "retornando",
+
+		"enxame.alcancou": "O enxame te alcançou!",
+
+		"jogo.fim":      "Fim de jogo! Pressione %s para sair.",
+		"jogo.pausado":  "Jogo pausado. Pressione %s para continuar.",
+		"jogo.retomado": "Jogo retomado",
+
+		"jogador.armadilha":         "Você pisou numa armadilha! Cuidado!",
+		"jogador.fantasma":          "Você passou através do fantasma... arrepiante!",
+		"jogador.parede":            "Você bateu na parede!",
+		"jogador.bloqueio_inimigo":  "Um inimigo está bloqueando o caminho!",
+		"jogador.bloqueio_guardiao": "O guardião não deixa você passar!",
+		"jogador.bloqueado":         "Caminho bloqueado!",
+		"jogador.preso":             "Você está preso no laço!",
+
+		"caminhada.aqui":          "Você já está aqui",
+		"caminhada.intransitavel": "Não é possível andar até (%d, %d)",
+		"caminhada.sem_caminho":   "Não há caminho até (%d, %d)",
+		"caminhada.iniciada":      "Caminhando até (%d, %d)...",
+
+		"inspecao.celula":            "(%d, %d): %s",
+		"inspecao.vazio":             "chão livre",
+		"inspecao.parede":            "uma parede sólida",
+		"inspecao.vegetacao":         "vegetação, dá para atravessar",
+		"inspecao.inimigo":           "um inimigo de patrulha",
+		"inspecao.perseguidor":       "um perseguidor do enxame",
+		"inspecao.portal":            "um portal aberto",
+		"inspecao.armadilha":         "uma armadilha de espinhos",
+		"inspecao.laco":              "um laço armado",
+		"inspecao.alarme":            "uma armadilha de alarme",
+		"inspecao.teleporte":         "uma armadilha de teletransporte",
+		"inspecao.armadilha_gasta":   "uma armadilha disparada, ainda sem rearmar",
+		"inspecao.armadilha_jogador": "uma armadilha sua",
+		"inspecao.isca":              "uma isca",
+		"inspecao.fantasma":          "um fantasma",
+		"inspecao.tesouro":           "um tesouro",
+		"inspecao.voce":              "você",
+		"inspecao.guardiao":          "o guardião (%s)",
+		"inspecao.desconhecido":      "algo desconhecido",
+
+		"interacao.portal":                 "Usando portal...",
+		"interacao.tesouro":                "Coletando tesouro!",
+		"interacao.vegetacao":              "Você examina a vegetação... nada interessante",
+		"interacao.parede":                 "Você toca na parede... é sólida",
+		"interacao.inimigo":                "O inimigo te olha ameaçadoramente!",
+		"interacao.guardiao_dormindo":      "O guardião dorme... por enquanto",
+		"interacao.guardiao_acordado":      "O guardião te encara, pronto para atacar!",
+		"interacao.nada":                   "Interagindo em (%d, %d) - nada acontece",
+		"interacao.armadilha":              "Você desarma a armadilha com cuidado...",
+		"interacao.armadilhas_encontradas": "Você procura em volta e encontra %d armadilha(s) escondida(s)!",
+
+		"acao.sair":          "Saindo do jogo...",
+		"acao.esperar":       "Você espera um instante...",
+		"acao.inventario":    "Inventário: %d tesouro(s), %d ponto(s), %d vida(s), %d armadilha(s), %d isca(s)",
+		"acao.salvo":         "Partida salva em %s",
+		"acao.salvar_falhou": "Não foi possível salvar a partida: %v",
+
+		"colocar.sem_espaco":     "Não há espaço livre ao seu lado",
+		"colocar.sem_armadilhas": "Você não tem mais armadilhas",
+		"colocar.sem_iscas":      "Você não tem mais iscas",
+
+		"hud.placar": "Vidas: %d  Pontos: %d  Tesouros: %d  Guardião: %s",
+		"hud.alerta": " [ALERTA!]",
+
+		"historico.titulo": "Histórico de mensagens (%d-%d de %d)",
+		"historico.ajuda":  "%s/%s rolar | %s ou %s fechar",
+
+		"ajuda.mover":      "mover",
+		"ajuda.interagir":  "interagir",
+		"ajuda.sair":       "sair",
+		"ajuda.pausar":     "pausa",
+		"ajuda.esperar":    "esperar",
+		"ajuda.armar":      "armadilha",
+		"ajuda.isca":       "isca",
+		"ajuda.inventario": "inventário",
+		"ajuda.mensagens":  "mensagens",
+		"ajuda.minimapa":   "minimapa",
+		"ajuda.salvar":     "salvar",
+
+		"tecla.espaco": "Espaço",
+		"tecla.seta":   "seta",
+		"tecla.setas":  "setas",
+
+		"minimapa.titulo":  "Mapa",
+		"minimapa.exibido": "Minimapa exibido",
+		"minimapa.oculto":  "Minimapa oculto",
+
+		"armadilha.nome.espinhos":  "espinhos",
+		"armadilha.nome.laco":      "laço",
+		"armadilha.nome.alarme":    "alarme",
+		"armadilha.nome.teleporte": "teleporte",
+
+		"estado.atordoado":          "atordoado",
+		"estado.patrulhando":        "patrulhando",
+		"estado.esperando_livre":    "esperando a partida ficar livre",
+		"estado.atraido":            "atraído pela isca em %d,%d",
+		"estado.parado_ponto":       "parado no ponto %d",
+		"estado.indo_ponto":         "indo ao ponto %d",
+		"estado.portal_fechado":     "fechado",
+		"estado.portal_usado":       "fechado (usado)",
+		"estado.portal_aberto":      "aberto",
+		"estado.portal_esgotado":    "fechado (tempo esgotado)",
+		"estado.perseguindo":        "perseguindo",
+		"estado.vagando":            "vagando",
+		"estado.aguardando":         "aguardando pedidos",
+		"estado.isca_colocada":      "isca colocada",
+		"estado.isca_sumiu":         "isca sumiu",
+		"estado.armadilha_jogador":  "armadilha do jogador",
+		"estado.armou":              "armou %s",
+		"estado.desarmou":           "desarmou",
+		"estado.expirou":            "expirou",
+		"estado.atordoou":           "atordoou %s",
+		"estado.rearmou":            "rearmou %s",
+		"estado.disparou":           "disparou %s",
+		"estado.encontrada":         "encontrada %s",
+		"estado.surgiu":             "surgiu",
+		"estado.coletado":           "coletado",
+		"estado.guardiao":           "%s%s%s",
+		"estado.sufixo_alerta":      " (alerta)",
+		"estado.sufixo_atordoado":   " (atordoado)",
+		"estado.enxame_perseguindo": "%d perseguindo",
+		"estado.enxame_atordoados":  "%d perseguindo, %d atordoados",
+		"estado.enxame_dispersando": "%d dispersando por %d pulsos",
+		"estado.controle_area":      "jogador na área do guardião há %d pulsos",
+		"estado.caminhada_faltam":   "faltam %d passos",
+		"estado.caminhada_parada":   "parada",
+
+		"depuracao.exibida": "Painel de depuração exibido",
+		"depuracao.oculta":  "Painel de depuração oculto",
+		"depuracao.titulo":  "Depuração  goroutines: %d  tempo: %v",
+		"depuracao.posicao": " em %d,%d",
+		"depuracao.filas":   "Filas (pendentes/capacidade):",
+		"depuracao.fila":    " %-12s %d/%s  entregues %v  descartados %d",
+		"depuracao.quadros": "Quadros: %d desenhados, %d pedidos agrupados, %d células no último",
+		"depuracao.relogio": "Relógio: passo %v, %d passos, %d descartados por atraso",
+		"depuracao.acesso":  "Intenções: %d aplicadas, %d com espera",
+		"depuracao.espera":  " espera média %v  última %v  máxima %v",
+
+		"narracao.inicio":       "Modo de narração, mapa %s. Digite um comando por linha; ? mostra os comandos.",
+		"narracao.ajuda":        "Comandos: w, a, s, d ou norte, oeste, sul, leste para andar; e interagir; f armadilha; r isca; linha vazia esperar; l descrever o entorno; i inventário; m últimas mensagens; salvar (a partida); q sair.",
+		"narracao.desconhecido": "Comando desconhecido: %q. Digite ? para ver os comandos.",
+		"narracao.perigo":       "Perigo! %s",
+		"narracao.fim":          "Fim de jogo. Você terminou com %d pontos.",
+		"narracao.posicao":      "Você está em (%d, %d). Vidas: %d. Pontos: %d. Tesouros: %d.",
+		"narracao.sob":          "Sob você: %s.",
+		"narracao.direcao":      "%s: %s.",
+		"narracao.norte":        "Norte",
+		"narracao.sul":          "Sul",
+		"narracao.oeste":        "Oeste",
+		"narracao.leste":        "Leste",
+		"narracao.limite":       "fim do mapa",
+		"narracao.tesouro":      "Tesouro mais próximo: %s.",
+		"narracao.sem_tesouro":  "Nenhum tesouro no mapa.",
+		"narracao.ameacas":      "Ameaças: %s.",
+		"narracao.ameaca":       "%s, %s",
+		"narracao.sem_ameacas":  "Nenhuma ameaça por perto.",
+		"narracao.rumo.norte":   "%d ao norte",
+		"narracao.rumo.sul":     "%d ao sul",
+		"narracao.rumo.oeste":   "%d a oeste",
+		"narracao.rumo.leste":   "%d a leste",
+		"narracao.rumo.e":       " e ",
+		"narracao.rumo.aqui":    "aqui mesmo",
+
+		"simulacao.resumo":  "mapa: %s  agente: %s  partidas: %d  sementes: %d-%d  passos: %d",
+		"simulacao.mortes":  "partidas sem vidas ao final: %d (%.1f%%)",
+		"simulacao.colunas": "métrica\tmín\tmédia\tp50\tp90\tmáx\t",
+
+		"erro.agente":              "agente desconhecido: %s",
+		"erro.duracao":             "duração deve ser um texto como \"800ms\": %s",
+		"erro.positivo":            "%s deve ser positivo",
+		"erro.minimo":              "%s deve ser pelo menos %d",
+		"erro.glifos":              "conjunto de glifos desconhecido: %q (prontos: %s)",
+		"erro.glifos_base":         "glifos %s: base desconhecida: %q",
+		"erro.glifos_elemento":     "glifos %s: elemento desconhecido: %q",
+		"erro.glifos_caractere":    "glifos %s: %s: o glifo deve ser um único caractere: %q",
+		"erro.glifos_digito":       "glifos %s: %s: dígitos são reservados para os marcadores de rota",
+		"erro.glifos_largura":      "glifos %s: %s: %q não ocupa uma ou duas colunas",
+		"erro.glifos_repetido":     "glifos %s: %q usado por %s e por %s",
+		"erro.modo_cores":          "modo de cores desconhecido: %q (use auto, basicas, 256 ou rgb)",
+		"erro.tema":                "tema desconhecido: %q (prontos: %s)",
+		"erro.tema_base":           "tema %s: base desconhecida: %q",
+		"erro.tema_elemento":       "tema %s: elemento desconhecido: %q",
+		"erro.tema_estilo":         "tema %s: %s: %v",
+		"erro.atributo":            "atributo desconhecido: %q",
+		"erro.cor_indice":          "índice de cor fora da paleta de 256 cores: %d",
+		"erro.cor":                 "cor desconhecida: %q",
+		"erro.layout":              "layout de teclado desconhecido: %q",
+		"erro.atalho":              "ação desconhecida nos atalhos: %q",
+		"erro.tecla_repetida":      "tecla %q ligada a %s e a %s",
+		"erro.sair_sem_tecla":      "a ação sair precisa de pelo menos uma tecla",
+		"erro.tecla":               "tecla desconhecida: %q",
+		"erro.simulacao_positivos": "-n, -paralelo e -passos devem ser positivos",
+		"erro.formato":             "formato desconhecido: %s",
+		"erro.dt":                  "-dt deve ser positivo",
+		"erro.idioma":              "idioma desconhecido: %q (disponíveis: %s)",
+		"erro.rota_diretiva":       "diretiva desconhecida: %q",
+		"erro.rota_ponto":          "ponto inválido na rota %s: %q",
+		"erro.rota_modo":           "modo desconhecido na rota %s: %q",
+		"erro.rota_positivo":       "deve ser positivo",
+		"erro.rota_opcao":          "opção desconhecida na rota %s: %q",
+		"erro.rota_valor":          "valor inválido para %s na rota %s: %v",
+		"erro.rota_vazia":          "rota %s não tem pontos",
+		"erro.rota_fora":           "ponto %d,%d da rota %s está fora do mapa ou bloqueado",
+		"erro.diario_tamanho":      "diário: o tamanho máximo deve ser positivo",
+		"erro.diario_copias":       "diário: o número de cópias não pode ser negativo",
+		"erro.diario":              "diário: %v",
+
+		"erro.salvamento":           "%s não é uma partida salva: %v",
+		"erro.salvamento_versao":    "%s foi salvo na versão %d do formato; este jogo lê a versão %d",
+		"erro.salvamento_elemento":  "partida salva: elemento desconhecido %q",
+		"erro.salvamento_armadilha": "partida salva: tipo de armadilha desconhecido %q",
+		"erro.salvamento_jogador":   "partida salva: o personagem em %d,%d está fora do mapa",
+		"erro.salvamento_posicao":   "partida salva: a posição %d,%d está fora do mapa",
+
+		"ajuda.bot":              "controla o personagem com um agente (aleatorio, guloso)",
+		"ajuda.intervalo_bot":    "tempo entre as ações do agente",
+		"ajuda.config":           "arquivo de configuração (padrão: config.json, se existir)",
+		"ajuda.idioma":           "idioma dos textos: pt-BR ou en (padrão: variável JOGO_IDIOMA ou pt-BR)",
+		"ajuda.log":              "grava os acontecimentos da partida neste arquivo, em JSON, um por linha",
+		"ajuda.log_tamanho":      "tamanho máximo do arquivo do -log, em MB, antes de girá-lo",
+		"ajuda.log_copias":       "quantos arquivos girados do -log são mantidos",
+		"ajuda.carregar":         "continua a partida gravada neste arquivo pela ação salvar",
+		"ajuda.narrar_dt":        "tempo de jogo que passa a cada ação",
+		"ajuda.narrar_semente":   "semente dos elementos aleatórios (padrão: o horário atual)",
+		"ajuda.simular_bot":      "agente que controla o personagem (aleatorio, guloso)",
+		"ajuda.simular_n":        "número de partidas",
+		"ajuda.simular_semente":  "primeira semente; as partidas usam sementes consecutivas",
+		"ajuda.simular_passos":   "máximo de passos por partida",
+		"ajuda.simular_paralelo": "partidas executadas ao mesmo tempo",
+		"ajuda.simular_dt":       "tempo de jogo de cada passo",
+		"ajuda.simular_formato":  "formato da saída: tabela (resumo) ou csv (uma linha por partida)",
+
+		"falha.jogo":           "o jogo falhou (%s): %s",
+		"falha.relatorio":      "relatório da falha em %s",
+		"falha.erro_relatorio": "não foi possível gravar o relatório da falha: %v",
+		"falha.titulo":         "O jogo falhou em %s",
+		"falha.goroutine":      "Goroutine: %s",
+		"falha.panico":         "Pânico: %s",
+		"falha.pendentes":      "Goroutines que não terminaram: %s",
+		"falha.sem_estado":     "O estado da partida não foi copiado, pois elas ainda podiam alterá-lo.",
+		"falha.estado":         "Estado da partida:",
+		"falha.jogador":        "Jogador em %d,%d  vidas %d  pontos %d",
+		"falha.estatisticas":   "Estatísticas: %+v",
+		"falha.semente":        "Semente: %d",
+		"falha.mensagens":      "Mensagens:",
+		"falha.mapa":           "Mapa:",
+	}
+}
@@ -2,6 +2,9 @@
 package main
 
 import (
+	"time"
+
+	"github.com/mattn/go-runewidth"
 	"github.com/nsf/termbox-go"
 )
 
@@ -21,146 +24,350 @@ const (
 
 // EventoTeclado representa uma ação detectada do teclado
 type EventoTeclado struct {
-	Tipo  string // "sair", "interagir", "mover"
-	Tecla rune   // Tecla pressionada, usada no caso de movimento
+	Tipo  string // "sair", "interagir", "mover", "esperar", "pausar", "inventario", "salvar", "clicar", "inspecionar"
+	Tecla rune   // Direção (w, a, s ou d), usada no caso de movimento
+	X, Y  int    // Célula da tela clicada, usada nos eventos de mouse
+}
+
+// Janela é o trecho do mapa visível no terminal: X e Y são a célula do mapa
+// desenhada no canto superior esquerdo da tela e Celula é quantas colunas do
+// terminal cada célula ocupa
+type Janela struct {
+	X, Y, Largura, Altura int
+	Celula                int
+}
+
+// Chave do tema de cada gravidade de mensagem
+var estilosGravidade = map[Gravidade]string{
+	GravidadeInfo:   "info",
+	GravidadeAviso:  "aviso",
+	GravidadePerigo: "perigo",
+}
+
+// Teclas em uso; trocadas por interfaceDefinirTeclas antes do jogo começar
+var teclasAtuais, _ = teclasMontar(ConfigTeclas{Layout: "qwerty"})
+
+// Define o mapeamento de teclas usado pela leitura do teclado e pela ajuda
+func interfaceDefinirTeclas(m *MapaTeclas) {
+	teclasAtuais = m
+}
+
+// Tema em uso; trocado por interfaceDefinirTema antes do jogo começar
+var temaAtual, _ = temaCarregar("padrao", termbox.OutputNormal)
+
+// Define o tema usado no desenho e o modo de cores do terminal
+func interfaceDefinirTema(t *Tema) {
+	temaAtual = t
+}
+
+// Glifos em uso; trocados por interfaceDefinirGlifos antes do jogo começar
+var glifosAtuais, _ = glifosCarregar("unicode")
+
+// Define o conjunto de glifos usado para desenhar o mapa
+func interfaceDefinirGlifos(g *ConjuntoGlifos) {
+	glifosAtuais = g
 }
 
 // Canal para serializar operações de desenho (evita corrupção visual)
 var canalDesenho = make(chan func(), 100)
-var desenhoAtivo = false
+var desenhoTerminado chan bool // fechado quando o worker de desenho termina (nil antes de iniciar)
 
-// Inicializa a interface gráfica usando termbox
-func interfaceIniciar() {
+// Inicializa a interface gráfica usando termbox. O worker de desenho entra
+// no grupo da partida, que espera por ele no encerramento.
+func interfaceIniciar(grupo *Grupo) error {
 	if err := termbox.Init(); err != nil {
-		panic(err)
+		return err
 	}
+	termbox.SetInputMode(termbox.InputEsc | termbox.InputMouse)
+	termbox.SetOutputMode(temaAtual.Modo)
 
 	// Inicia o worker de desenho em goroutine separada
-	iniciarWorkerDesenho()
+	iniciarWorkerDesenho(grupo)
+	return nil
 }
 
-// Worker que processa todas as operações de desenho sequencialmente
-func iniciarWorkerDesenho() {
-	desenhoAtivo = true
-	go func() {
-		for operacao := range canalDesenho {
-			if operacao != nil {
-				operacao()
+// Worker que processa todas as operações de desenho sequencialmente, e os
+// quadros do jogo no máximo na taxa configurada, até a partida terminar
+func iniciarWorkerDesenho(grupo *Grupo) {
+	desenhoTerminado = make(chan bool)
+	grupo.Iniciar("desenho", func() {
+		defer close(desenhoTerminado)
+		var ultimo time.Time
+		for {
+			select {
+			case <-grupo.Contexto().Done():
+				return
+			case operacao, ok := <-canalDesenho:
+				if !ok {
+					return
+				}
+				if operacao != nil {
+					operacao()
+				}
+			case jogo := <-pedidoQuadro:
+				ultimo = desenharQuadro(jogo, ultimo)
 			}
 		}
-		desenhoAtivo = false
-	}()
+	})
 }
 
 // Encerra o uso da interface termbox
 func interfaceFinalizar() {
 	// Para o worker de desenho
-	if desenhoAtivo {
+	if desenhoTerminado != nil {
 		close(canalDesenho)
-		// Aguarda worker finalizar
-		for desenhoAtivo {
-			// Busy wait simples
-		}
+		// Aguarda worker finalizar as operações pendentes
+		<-desenhoTerminado
 	}
 	termbox.Close()
 }
 
+// Fechado quando uma leitura do teclado recebe a interrupção
+var interrupcaoRecebida = make(chan bool)
+
+// Acorda a leitura do teclado em andamento, que devolve um evento vazio.
+// Espera até alguém ler o teclado.
+func interfaceInterromperLeitura() {
+	termbox.Interrupt()
+}
+
+// Lê o teclado até chegar a interrupção pedida, se nenhuma leitura a
+// recebeu, para que quem a pediu não fique esperando para sempre. Só pode
+// ser chamada depois que as outras leituras do teclado pararam.
+func interfaceReceberInterrupcao() {
+	for {
+		select {
+		case <-interrupcaoRecebida:
+			return
+		default:
+		}
+		if termbox.PollEvent().Type == termbox.EventInterrupt {
+			return
+		}
+	}
+}
+
 // Lê um evento do teclado e o traduz para um EventoTeclado
 func interfaceLerEventoTeclado() EventoTeclado {
 	ev := termbox.PollEvent()
-	if ev.Type != termbox.EventKey {
+	if ev.Type == termbox.EventInterrupt {
+		select {
+		case <-interrupcaoRecebida:
+		default:
+			close(interrupcaoRecebida)
+		}
 		return EventoTeclado{}
 	}
-	if ev.Key == termbox.KeyEsc {
-		return EventoTeclado{Tipo: "sair"}
-	}
-	if ev.Ch == 'e' || ev.Ch == 'E' {
-		return EventoTeclado{Tipo: "interagir"}
+	if ev.Type == termbox.EventMouse {
+		// Botão esquerdo anda até a célula; o direito descreve o que há nela
+		switch ev.Key {
+		case termbox.MouseLeft:
+			return EventoTeclado{Tipo: "clicar", X: ev.MouseX, Y: ev.MouseY}
+		case termbox.MouseRight:
+			return EventoTeclado{Tipo: "inspecionar", X: ev.MouseX, Y: ev.MouseY}
+		}
+		return EventoTeclado{}
 	}
-	return EventoTeclado{Tipo: "mover", Tecla: ev.Ch}
-}
-
-// Renderiza todo o estado atual do jogo na tela de forma thread-safe
-func interfaceDesenharJogo(jogo *Jogo) {
-	// Envia operação de desenho para o worker
-	select {
-	case canalDesenho <- func() { renderizarJogoSeguro(jogo) }:
-	default:
-		// Se canal estiver cheio, ignora esta renderização para evitar bloqueio
+	if ev.Type != termbox.EventKey {
+		return EventoTeclado{}
 	}
+	// Teclas sem ação não fazem nada
+	evento, _ := teclasAtuais.Acao(ev.Ch, ev.Key)
+	return evento
 }
 
 // Função interna que faz a renderização real (executada pelo worker)
 func renderizarJogoSeguro(jogo *Jogo) {
-	// Obtem acesso exclusivo ao estado do jogo
-	obterAcessoMapa()
-	defer liberarAcessoMapa()
+	// Desenha a partir de um instantâneo do dono do jogo, sem parar os
+	// elementos enquanto a tela é montada
+	inst := jogoInstantaneo(jogo)
+	if inst == nil {
+		return // partida encerrada
+	}
 
-	// Cria uma cópia local do estado para renderização
-	mapaLocal := make([][]Elemento, len(jogo.Mapa))
-	for i := range jogo.Mapa {
-		mapaLocal[i] = make([]Elemento, len(jogo.Mapa[i]))
-		copy(mapaLocal[i], jogo.Mapa[i])
+	largura, altura := termbox.Size()
+	if inst.HistoricoAberto {
+		limite := desenharHistorico(inst, largura, altura)
+		jogoExecutar(jogo, func() { jogo.historicoRolagem = min(jogo.historicoRolagem, limite) })
+		termbox.Flush()
+		quadroAnterior = nil
+		return
 	}
-	posX, posY := jogo.PosX, jogo.PosY
-	statusMsg := jogo.StatusMsg
 
-	// Limpa a tela
-	termbox.Clear(CorPadrao, CorPadrao)
+	// Escolhe o trecho visível do mapa e o guarda para traduzir os cliques.
+	// Abaixo do mapa ficam uma linha vazia, as mensagens, o placar e a ajuda.
+	cfg := jogo.config.Mensagens
+	celula := glifosAtuais.Largura
+	janela := interfaceJanela(inst.Mapa, inst.Jogador.X, inst.Jogador.Y, largura/celula, altura-cfg.Linhas-3)
+	janela.Celula = celula
+	jogoExecutar(jogo, func() { jogo.janela = janela })
 
-	// Desenha todos os elementos do mapa
-	for y, linha := range mapaLocal {
-		for x, elem := range linha {
-			if x < 80 && y < 30 { // Limita às dimensões seguras do terminal
-				termbox.SetCell(x, y, elem.simbolo, elem.cor, elem.corFundo)
-			}
-		}
+	mapaLocal := inst.Mapa
+	placar := traduzir("hud.placar", inst.Vida, inst.Pontos, inst.Stats.TesourosColetados, inst.EstadoGuardiao.Nome())
+
+	// Minimapa, quando o mapa não cabe inteiro na tela
+	minimapa := inst.MinimapaVisivel && (janela.Largura < larguraDoMapa(mapaLocal) || janela.Altura < len(mapaLocal))
+
+	// Com o mesmo layout do quadro anterior só o que mudou é redesenhado; o
+	// painel de depuração, que muda a cada quadro, obriga a redesenhar tudo
+	layout := LayoutQuadro{largura: largura, altura: altura, linhas: len(mapaLocal), janela: janela, minimapa: minimapa, painel: inst.Depuracao != nil}
+	anterior := quadroAnterior
+	if anterior == nil || anterior.layout != layout || inst.Depuracao != nil {
+		anterior = nil
+		termbox.Clear(CorPadrao, CorPadrao)
+	} else {
+		limparLinhas(janela.Altura, largura, altura)
 	}
 
-	// Desenha o personagem sobre o mapa (se estiver em posição válida)
-	if posX >= 0 && posX < 80 && posY >= 0 && posY < 30 {
-		termbox.SetCell(posX, posY, Personagem.simbolo, Personagem.cor, Personagem.corFundo)
+	// Desenha o trecho visível do mapa e o personagem
+	desenharMapa(inst, janela, anterior)
+
+	if minimapa {
+		desenharMinimapa(mapaLocal, inst.Jogador, largura, janela.Altura)
 	}
 
+	// Painel de depuração sobre o canto superior esquerdo do mapa
+	if inst.Depuracao != nil {
+		desenharPainel(inst.Depuracao, largura, janela.Altura)
+	}
+	quadroAnterior = &Quadro{layout: layout, mapa: mapaLocal, jogador: inst.Jogador}
+
 	// Desenha a barra de status
-	desenharBarraDeStatusSegura(statusMsg, len(mapaLocal))
+	desenharBarraDeStatusSegura(inst.Recentes, cfg.Linhas, placar, inst.AlertaGuardiao, janela.Altura)
 
 	// Força a atualização do terminal
 	termbox.Flush()
 }
 
+// Calcula o trecho do mapa que cabe na área indicada, centrado no personagem
+// e sem passar das bordas do mapa
+func interfaceJanela(mapa [][]Elemento, posX, posY, largura, altura int) Janela {
+	larguraMapa := larguraDoMapa(mapa)
+	j := Janela{Largura: min(largura, larguraMapa), Altura: min(max(altura, 1), len(mapa))}
+	j.X = min(max(posX-j.Largura/2, 0), larguraMapa-j.Largura)
+	j.Y = min(max(posY-j.Altura/2, 0), len(mapa)-j.Altura)
+	return j
+}
+
+// Desenha um elemento na coluna x com o glifo e o estilo em uso. Quando as
+// células têm duas colunas, glifos estreitos são completados com um espaço.
+func desenharCelula(x, y int, elem Elemento) {
+	estilo := temaAtual.Elemento(elem)
+	g := glifosAtuais.Glifo(elem)
+	termbox.SetCell(x, y, g, estilo.Frente, estilo.Fundo)
+	for i := runewidth.RuneWidth(g); i < glifosAtuais.Largura; i++ {
+		termbox.SetCell(x+i, y, ' ', estilo.Frente, estilo.Fundo)
+	}
+}
+
+// Largura da linha mais longa do mapa
+func larguraDoMapa(mapa [][]Elemento) int {
+	largura := 0
+	for _, linha := range mapa {
+		largura = max(largura, len(linha))
+	}
+	return largura
+}
+
+// Escreve um texto a partir da coluna x, cortado na largura indicada, com o
+// estilo do tema indicado pela chave. Caracteres largos ocupam duas colunas.
+func desenharTexto(x, y int, texto string, largura int, chave string) {
+	estilo := temaAtual.Estilo(chave)
+	coluna := 0
+	for _, c := range texto {
+		w := runewidth.RuneWidth(c)
+		if coluna+w > largura {
+			break
+		}
+		termbox.SetCell(x+coluna, y, c, estilo.Frente, estilo.Fundo)
+		coluna += w
+	}
+}
+
+// Desenha um painel de texto no canto superior esquerdo, com fundo próprio
+// para não se misturar ao mapa, cortado na largura e altura indicadas
+func desenharPainel(linhas []string, largura, altura int) {
+	larguraPainel := 0
+	for _, linha := range linhas {
+		larguraPainel = max(larguraPainel, runewidth.StringWidth(linha)+1)
+	}
+	larguraPainel = min(larguraPainel, largura)
+	estilo := temaAtual.Estilo("painel")
+	for y, linha := range linhas {
+		if y >= altura {
+			break
+		}
+		for x := 0; x < larguraPainel; x++ {
+			termbox.SetCell(x, y, ' ', estilo.Frente, estilo.Fundo)
+		}
+		desenharTexto(0, y, linha, larguraPainel, "painel")
+	}
+}
+
 // Exibe uma barra de status com informações úteis ao jogador
-func desenharBarraDeStatusSegura(statusMsg string, alturaJogo int) {
-	// Limita o tamanho da mensagem para evitar overflow
-	if len(statusMsg) > 78 {
-		statusMsg = statusMsg[:78]
-	}
-
-	// Linha de status dinâmica
-	linhaStatus := alturaJogo + 1
-	if linhaStatus < 30 { // Verifica se cabe na tela
-		for i, c := range statusMsg {
-			if i < 79 { // Limita largura
-				termbox.SetCell(i, linhaStatus, c, CorTexto, CorPadrao)
-			}
+func desenharBarraDeStatusSegura(mensagens []Mensagem, linhas int, placar string, alerta bool, alturaJogo int) {
+	_, alturaTela := termbox.Size()
+
+	// Últimas mensagens, a mais nova embaixo, coloridas pela gravidade
+	for i, m := range mensagens {
+		linha := alturaJogo + 1 + linhas - len(mensagens) + i
+		if linha < alturaTela { // Verifica se cabe na tela
+			desenharTexto(0, linha, m.Resumo(), 78, estilosGravidade[m.Gravidade])
 		}
 	}
 
-	// Instruções fixas
-	msg := "Use WASD para mover e E para interagir. ESC para sair."
-	linhaInstrucoes := alturaJogo + 3
-	if linhaInstrucoes < 30 && len(msg) < 79 {
-		for i, c := range msg {
-			termbox.SetCell(i, linhaInstrucoes, c, CorTexto, CorPadrao)
+	// Placar do jogador
+	linhaPlacar := alturaJogo + linhas + 1
+	if linhaPlacar < alturaTela && len([]rune(placar)) < 79 {
+		desenharTexto(0, linhaPlacar, placar, 79, "texto")
+		// Indicador de alarme do guardião
+		i := len([]rune(placar))
+		indicador := traduzir("hud.alerta")
+		if alerta && i+len([]rune(indicador)) < 79 {
+			desenharTexto(i, linhaPlacar, indicador, 79-i, "alerta")
 		}
 	}
+
+	// Instruções montadas a partir das teclas em uso
+	msg := teclasAtuais.Ajuda(78)
+	linhaInstrucoes := alturaJogo + linhas + 2
+	if linhaInstrucoes < alturaTela {
+		desenharTexto(0, linhaInstrucoes, msg, 78, "texto")
+	}
+}
+
+// Desenha o histórico de mensagens ocupando a tela inteira, com a rolagem do
+// instantâneo. Retorna a maior rolagem que ainda deixa a tela cheia.
+func desenharHistorico(inst *Instantaneo, largura, altura int) int {
+	termbox.Clear(CorPadrao, CorPadrao)
+	todas := inst.Historico
+	visiveis := max(altura-2, 1)
+
+	// A rolagem conta as mensagens puladas a partir da mais nova
+	limite := max(len(todas)-visiveis, 0)
+	fim := len(todas) - min(inst.HistoricoRolagem, limite)
+	inicio := max(fim-visiveis, 0)
+
+	titulo := traduzir("historico.titulo", min(inicio+1, fim), fim, len(todas))
+	desenharTexto(0, 0, titulo, largura, "titulo")
+	for i, m := range todas[inicio:fim] {
+		desenharTexto(0, i+1, m.Formatar(), largura, estilosGravidade[m.Gravidade])
+	}
+	ajuda := traduzir("historico.ajuda", teclasAtuais.Rotulo("cima"), teclasAtuais.Rotulo("baixo"),
+		teclasAtuais.Rotulo("mensagens"), teclasAtuais.Rotulo("sair"))
+	desenharTexto(0, altura-1, ajuda, largura, "texto")
+	return limite
 }
 
 // Limpa a tela do terminal (função de conveniência)
 func interfaceLimparTela() {
 	select {
-	case canalDesenho <- func() { termbox.Clear(CorPadrao, CorPadrao) }:
+	case canalDesenho <- func() {
+		termbox.Clear(CorPadrao, CorPadrao)
+		quadroAnterior = nil
+	}:
 	default:
+		desenhosDescartados.Incrementar()
 	}
 }
 
@@ -169,6 +376,7 @@ func interfaceAtualizarTela() {
 	select {
 	case canalDesenho <- func() { termbox.Flush() }:
 	default:
+		desenhosDescartados.Incrementar()
 	}
 }
 
@@ -177,9 +385,11 @@ func interfaceDesenharElemento(x, y int, elem Elemento) {
 	select {
 	case canalDesenho <- func() {
 		if x >= 0 && x < 80 && y >= 0 && y < 30 {
-			termbox.SetCell(x, y, elem.simbolo, elem.cor, elem.corFundo)
+			desenharCelula(x*glifosAtuais.Largura, y, elem)
+			quadroAnterior = nil
 		}
 	}:
 	default:
+		desenhosDescartados.Incrementar()
 	}
 }
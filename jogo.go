@@ -3,23 +3,85 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/rand"
 	"os"
+	"strings"
+	"time"
 )
 
 // Elemento representa qualquer objeto do mapa (parede, personagem, vegetação, etc)
 type Elemento struct {
-	simbolo   rune
-	cor       Cor
-	corFundo  Cor
-	tangivel  bool // Indica se o elemento bloqueia passagem
+	simbolo  rune
+	cor      Cor
+	corFundo Cor
+	tangivel bool // Indica se o elemento bloqueia passagem
 }
 
 // Jogo contém o estado atual do jogo
 type Jogo struct {
-	Mapa            [][]Elemento // grade 2D representando o mapa
-	PosX, PosY      int          // posição atual do personagem
-	UltimoVisitado  Elemento     // elemento que estava na posição do personagem antes de mover
-	StatusMsg       string       // mensagem para a barra de status
+	Mapa           [][]Elemento       // grade 2D representando o mapa
+	PosX, PosY     int                // posição atual do personagem
+	UltimoVisitado Elemento           // elemento que estava na posição do personagem antes de mover
+	Mensagens      *RegistroMensagens // histórico de mensagens exibidas ao jogador
+	Vida           int                // vidas restantes; a partida termina quando chega a zero
+	Pontos         int                // pontuação acumulada
+	Stats          Estatisticas       // contadores do que aconteceu na partida
+	Inventario     Inventario         // o que o jogador pode colocar no mapa
+	EstadoGuardiao EstadoGuardiao     // o que o guardião está fazendo, exibido na barra de status
+	AlertaGuardiao bool               // o guardião recebeu um alarme e sabe onde o jogador está
+	Rotas          []*RotaPatrulha    // rotas de patrulha definidas no arquivo do mapa
+
+	janela           Janela  // trecho do mapa desenhado por último, para traduzir cliques
+	caminhoJogador   []Ponto // passos restantes da caminhada escolhida com o mouse
+	historicoAberto  bool    // a tela de histórico de mensagens está sendo exibida
+	historicoRolagem int     // mensagens puladas a partir da mais nova na tela de histórico
+	minimapaVisivel  bool    // o minimapa é desenhado quando o mapa não cabe na tela
+	depuracaoVisivel bool    // o painel de depuração é desenhado sobre o mapa
+
+	armadilhas map[Ponto]*ArmadilhaInstalada // armadilhas no mapa, inclusive as escondidas
+	presoAte   time.Duration                 // o laço prende o personagem até este instante do relógio
+	iscas      map[Ponto]time.Duration       // iscas no mapa e o instante em que somem
+	atordoados map[string]time.Duration      // elementos atordoados, pelo nome, até o instante indicado
+	direcao    Ponto                         // direção do último passo, para onde o personagem coloca o que tem
+
+	estados map[string]EstadoElemento // último estado informado por cada elemento
+	espera  EsperaIntencoes           // tempo de espera pelo dono do jogo
+	diario  *Diario                   // diário da sessão (nil se não há)
+	copia   [][]Elemento              // mapa do último instantâneo, com linhas reaproveitáveis
+
+	config    *Configuracao   // parâmetros dos elementos; não muda durante a partida
+	relogio   *Relogio        // fonte de tempo dos elementos
+	eventos   *Barramento     // eventos trocados entre os elementos
+	intencoes chan Intencao   // alterações pedidas ao dono do jogo
+	encerrado <-chan struct{} // fechado quando o dono para de aplicar intenções
+	grupo     *Grupo          // goroutines da partida
+	mapa      string          // arquivo do mapa de onde a partida começou
+	semente   int64           // semente usada para derivar os geradores aleatórios
+	geradores int64           // quantidade de geradores aleatórios já criados
+	semTela   bool            // partida sem interface gráfica (agentes e simulações)
+	fluxo     *MapaFluxo      // distâncias até o jogador, compartilhadas pelos perseguidores
+	fluxoFuga *MapaFluxo      // variante do fluxo para fugir do jogador
+
+	salvar    map[string]func() any      // como cada elemento guarda o seu estado no salvamento
+	carregado map[string]json.RawMessage // estado dos elementos no salvamento carregado (nil numa partida nova)
+}
+
+// Estatisticas acumula o que aconteceu com o personagem durante a partida
+type Estatisticas struct {
+	TesourosColetados   int
+	ArmadilhasAtingidas int
+	PortaisUsados       int
+	CapturasFantasma    int
+	CapturasEnxame      int
+	AtaquesGuardiao     int
+}
+
+// Inventario guarda o que o jogador pode colocar nas células ao lado
+type Inventario struct {
+	Armadilhas int
+	Iscas      int
 }
 
 // Elementos visuais do jogo
@@ -32,13 +94,76 @@ var (
 )
 
 // Cria e retorna uma nova instância do jogo
-func jogoNovo() Jogo {
+func jogoNovo(semente int64, relogio *Relogio, config *Configuracao) Jogo {
 	// O ultimo elemento visitado é inicializado como vazio
 	// pois o jogo começa com o personagem em uma posição vazia
-	return Jogo{UltimoVisitado: Vazio}
+	jogo := Jogo{
+		UltimoVisitado: Vazio,
+		Vida:           config.Jogador.Vidas,
+		Mensagens:      registroNovo(config.Mensagens.Limite),
+		armadilhas:     map[Ponto]*ArmadilhaInstalada{},
+		iscas:          map[Ponto]time.Duration{},
+		atordoados:     map[string]time.Duration{},
+		salvar:         map[string]func() any{},
+		config:         config,
+		relogio:        relogio,
+		eventos:        barramentoNovo(),
+		intencoes:      make(chan Intencao),
+		semente:        semente,
+	}
+	jogo.Inventario = Inventario{Armadilhas: config.Jogador.Armadilhas, Iscas: config.Jogador.Iscas}
+	jogo.minimapaVisivel = config.Aparencia.Minimapa
+	return jogo
+}
+
+// Cria um gerador aleatório próprio para um elemento. Cada elemento recebe o
+// seu, derivado da semente da partida, para que partidas com a mesma semente
+// se repitam e os elementos não disputem um gerador compartilhado.
+func jogoNovoAleatorio(jogo *Jogo) *rand.Rand {
+	var n int64
+	jogoExecutar(jogo, func() {
+		jogo.geradores++
+		n = jogo.geradores
+	})
+	return rand.New(rand.NewSource(jogo.semente*1000003 + n))
 }
 
-// Lê um arquivo texto linha por linha e constrói o mapa do jogo
+// Verifica se a partida terminou (chamada pelo dono do jogo)
+func jogoTerminou(jogo *Jogo) bool {
+	return jogo.Vida <= 0
+}
+
+// Leva o personagem para uma posição livre sorteada na área de surgimento e
+// retorna onde ele ficou; sem posição livre ele fica onde está (chamada pelo
+// dono do jogo)
+func jogoTeletransportar(jogo *Jogo, rng *rand.Rand) Ponto {
+	for i := 0; i < 10; i++ {
+		p := jogo.config.Controle.AreaSurgimento.Sortear(rng)
+		if posicaoValida(p.X, p.Y, jogo) && jogoPodeMoverPara(jogo, p.X, p.Y) {
+			jogo.PosX, jogo.PosY = p.X, p.Y
+			jogo.caminhoJogador = nil
+			break
+		}
+	}
+	return Ponto{jogo.PosX, jogo.PosY}
+}
+
+// Tira uma vida e pontos do personagem (chamada pelo dono do jogo)
+func jogoFerirPersonagem(jogo *Jogo, causa string, penalidade int) {
+	if jogoTerminou(jogo) {
+		return
+	}
+	jogo.Vida--
+	jogo.Pontos -= penalidade
+	jogoPublicar(jogo, JogadorFerido{Causa: causa, Penalidade: penalidade, Vida: jogo.Vida})
+	if jogoTerminou(jogo) {
+		jogoMensagem(jogo, GravidadePerigo, "jogo", "jogo.fim", teclasAtuais.Rotulo("sair"))
+	}
+}
+
+// Lê um arquivo texto linha por linha e constrói o mapa do jogo. O arquivo
+// pode começar com um cabeçalho de diretivas (rotas de patrulha), separado do
+// mapa por uma linha contendo apenas "---".
 func jogoCarregarMapa(nome string, jogo *Jogo) error {
 	arq, err := os.Open(nome)
 	if err != nil {
@@ -46,30 +171,91 @@ func jogoCarregarMapa(nome string, jogo *Jogo) error {
 	}
 	defer arq.Close()
 
+	var linhas []string
 	scanner := bufio.NewScanner(arq)
-	y := 0
 	for scanner.Scan() {
-		linha := scanner.Text()
+		linhas = append(linhas, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// Separa e interpreta o cabeçalho, se houver
+	rotas := make(map[string]*RotaPatrulha)
+	var ordemRotas []string
+	for i, linha := range linhas {
+		if strings.TrimSpace(linha) != separadorCabecalho {
+			continue
+		}
+		for n, diretiva := range linhas[:i] {
+			diretiva = strings.TrimSpace(diretiva)
+			if diretiva == "" || strings.HasPrefix(diretiva, "//") {
+				continue
+			}
+			rota, err := rotaInterpretar(diretiva)
+			if err != nil {
+				return fmt.Errorf("%s:%d: %v", nome, n+1, err)
+			}
+			if _, repetida := rotas[rota.Nome]; !repetida {
+				ordemRotas = append(ordemRotas, rota.Nome)
+			}
+			rotas[rota.Nome] = rota
+		}
+		linhas = linhas[i+1:]
+		break
+	}
+
+	// O mapa pode ter sido desenhado com qualquer conjunto de glifos
+	conjunto, _ := glifosCarregar(jogo.config.Aparencia.Glifos)
+	leitura := glifosLeitura(conjunto)
+
+	// Marcadores numéricos desenhados no mapa, indexados pelo dígito
+	var marcadores [10]*Ponto
+
+	for y, texto := range linhas {
+		linha := []rune(texto) // índices por caractere, não por byte
 		var linhaElems []Elemento
 		for x, ch := range linha {
-			e := Vazio
-			switch ch {
-			case Parede.simbolo:
-				e = Parede
-			case Inimigo.simbolo:
-				e = Inimigo
-			case Vegetacao.simbolo:
-				e = Vegetacao
-			case Personagem.simbolo:
+			e, ok := leitura[ch]
+			if !ok {
+				e = Vazio
+			}
+			if e == Personagem {
 				jogo.PosX, jogo.PosY = x, y // registra a posição inicial do personagem
+				e = Vazio
+			}
+			if ch >= '0' && ch <= '9' {
+				marcadores[ch-'0'] = &Ponto{x, y}
 			}
 			linhaElems = append(linhaElems, e)
 		}
 		jogo.Mapa = append(jogo.Mapa, linhaElems)
-		y++
 	}
-	if err := scanner.Err(); err != nil {
-		return err
+
+	// Os marcadores formam a rota "marcadores", que pode ter opções no cabeçalho
+	var pontosMarcados []Ponto
+	for _, p := range marcadores {
+		if p != nil {
+			pontosMarcados = append(pontosMarcados, *p)
+		}
+	}
+	if len(pontosMarcados) > 0 {
+		rota, ok := rotas[rotaMarcadores]
+		if !ok {
+			rota = rotaNova(rotaMarcadores)
+			rotas[rotaMarcadores] = rota
+			ordemRotas = append(ordemRotas, rotaMarcadores)
+		}
+		if len(rota.Pontos) == 0 {
+			rota.Pontos = pontosMarcados
+		}
+	}
+
+	for _, nomeRota := range ordemRotas {
+		jogo.Rotas = append(jogo.Rotas, rotas[nomeRota])
+	}
+	if err := rotasValidar(jogo.Rotas, jogo.Mapa); err != nil {
+		return fmt.Errorf("%s: %v", nome, err)
 	}
 	return nil
 }
@@ -102,7 +288,7 @@ func jogoMoverElemento(jogo *Jogo, x, y, dx, dy int) {
 	// Obtem elemento atual na posição
 	elemento := jogo.Mapa[y][x] // guarda o conteúdo atual da posição
 
-	jogo.Mapa[y][x] = jogo.UltimoVisitado     // restaura o conteúdo anterior
-	jogo.UltimoVisitado = jogo.Mapa[ny][nx]   // guarda o conteúdo atual da nova posição
-	jogo.Mapa[ny][nx] = elemento              // move o elemento
+	jogo.Mapa[y][x] = jogo.UltimoVisitado   // restaura o conteúdo anterior
+	jogo.UltimoVisitado = jogo.Mapa[ny][nx] // guarda o conteúdo atual da nova posição
+	jogo.Mapa[ny][nx] = elemento            // move o elemento
 }
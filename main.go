@@ -2,100 +2,201 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
 func main() {
-	interfaceIniciar()
-	defer interfaceFinalizar()
+	// Modo ambiente: o jogo é controlado por comandos JSON em stdin/stdout
+	if len(os.Args) > 1 && os.Args[1] == "ambiente" {
+		if err := idiomaEscolher(""); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if err := ambienteExecutar(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Modo simulação: roda várias partidas sem interface e resume os resultados
+	if len(os.Args) > 1 && (os.Args[1] == "simular" || os.Args[1] == "simulate") {
+		if err := simularComando(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	// Modo de narração: o jogo descrito em texto, para leitores de tela
+	if len(os.Args) > 1 && (os.Args[1] == "narrar" || os.Args[1] == "narrate") {
+		if err := narrarComando(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	idiomaAntecipar(os.Args[1:])
+	bot := flag.String("bot", "", traduzir("ajuda.bot"))
+	intervaloBot := flag.Duration("intervalo-bot", 200*time.Millisecond, traduzir("ajuda.intervalo_bot"))
+	arquivoConfig := flag.String("config", "", traduzir("ajuda.config"))
+	idioma := flag.String("idioma", "", traduzir("ajuda.idioma"))
+	arquivoDiario := flag.String("log", "", traduzir("ajuda.log"))
+	tamanhoDiario := flag.Int("log-tamanho", 10, traduzir("ajuda.log_tamanho"))
+	copiasDiario := flag.Int("log-copias", 3, traduzir("ajuda.log_copias"))
+	carregar := flag.String("carregar", "", traduzir("ajuda.carregar"))
+	flag.Parse()
+
+	if err := idiomaEscolher(*idioma); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
 	mapaFile := "mapa.txt"
-	if len(os.Args) > 1 {
-		mapaFile = os.Args[1]
-	}
-
-	jogo := jogoNovo()
-	if err := jogoCarregarMapa(mapaFile, &jogo); err != nil {
-		panic(err)
-	}
-
-	// Inicializa o sistema de exclusão mútua
-	iniciarMutexMapa()
-
-	// Criação dos canais para comunicação entre elementos
-	portalChan := make(chan MsgPortal, 5)
-	armadilhaChan := make(chan MsgArmadilha, 10)
-	fantasmaChan := make(chan MsgFantasma, 5)
-	tesouroChan := make(chan MsgTesouro, 5)
-	guardianChan := make(chan MsgGuardian, 5)
-	done := make(chan bool)
-
-	// Inicia todos os elementos concorrentes
-	iniciarInimigoPatrulha(&jogo, 10, 5, done)
-	iniciarPortal(&jogo, portalChan, done)
-	iniciarArmadilha(&jogo, armadilhaChan, done)
-	iniciarFantasma(&jogo, fantasmaChan, done)
-	iniciarTesouro(&jogo, tesouroChan, done)
-	iniciarGuardian(&jogo, guardianChan, done)
-
-	// Inicia o sistema de controle central que coordena os elementos
-	iniciarControleCentral(&jogo, fantasmaChan, guardianChan, tesouroChan, armadilhaChan, done)
-
-	// Goroutine para gerenciar interações automáticas
-	go gerenciarInteracoes(&jogo, portalChan, tesouroChan, done)
-
-	// Primeira renderização
-	interfaceDesenharJogo(&jogo)
-
-	// Loop principal do jogo
-	for {
-		evento := interfaceLerEventoTeclado()
-		if continuar := personagemExecutarAcao(evento, &jogo, portalChan, tesouroChan); !continuar {
-			// Sinaliza para todas as goroutines pararem
-			close(done)
-			// Aguarda um pouco para as goroutines terminarem graciosamente
-			time.Sleep(100 * time.Millisecond)
-			break
+	if flag.NArg() > 0 {
+		mapaFile = flag.Arg(0)
+	}
+
+	// Uma partida salva continua no mapa de onde começou
+	var salvamento *Salvamento
+	if *carregar != "" {
+		s, err := salvamentoLer(*carregar)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		salvamento, mapaFile = s, s.Mapa
+	}
+
+	// Confere a configuração antes de tomar conta do terminal
+	config, err := configCarregar(*arquivoConfig, mapaFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	teclas, err := teclasMontar(config.Teclas)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	interfaceDefinirTeclas(teclas)
+
+	modoCores, err := modoCoresEscolher(config.Aparencia.Cores)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	tema, err := temaCarregar(config.Aparencia.Tema, modoCores)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	interfaceDefinirTema(tema)
+
+	glifos, err := glifosCarregar(config.Aparencia.Glifos)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	interfaceDefinirGlifos(glifos)
+	interfaceDefinirQuadros(config.Aparencia.Quadros)
+
+	var agente Agente
+	if *bot != "" {
+		criar, ok := agentesDisponiveis[*bot]
+		if !ok {
+			fmt.Fprintln(os.Stderr, traduzir("erro.agente", *bot))
+			os.Exit(2)
 		}
-		interfaceDesenharJogo(&jogo)
+		agente = criar(time.Now().UnixNano())
 	}
+
+	var diario *Diario
+	if *arquivoDiario != "" {
+		diario, err = diarioAbrir(*arquivoDiario, int64(*tamanhoDiario)<<20, *copiasDiario)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	// Os sinais de término encerram a partida como a tecla de sair
+	ctx, pararSinais := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	codigo := jogar(ctx, mapaFile, salvamento, config, diario, agente, *intervaloBot)
+	pararSinais()
+
+	// O diário é fechado depois de a interface devolver o terminal, para que
+	// um erro de escrita apareça na tela
+	if err := diario.Fechar(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(codigo)
 }
 
-// Função para gerenciar interações automáticas baseadas na posição do jogador
-func gerenciarInteracoes(jogo *Jogo, portalChan chan MsgPortal, tesouroChan chan MsgTesouro, done chan bool) {
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-done:
-			return
-		case <-ticker.C:
-			// Verifica se jogador está sobre um portal
-			obterAcessoMapa()
-			elementoAtual := jogo.Mapa[jogo.PosY][jogo.PosX]
-
-			if elementoAtual.simbolo == Portal.simbolo {
-				// Auto-uso do portal após 1 segundo
-				go func(x, y int) {
-					time.Sleep(1 * time.Second)
-					select {
-					case portalChan <- MsgPortal{X: x, Y: y, Cmd: "usar"}:
-					default:
-					}
-				}(jogo.PosX, jogo.PosY)
-			}
+// Joga uma partida no terminal e retorna o código de saída do programa. O
+// mapa, ou a partida salva, é carregado antes de o terminal ser configurado,
+// e o terminal é devolvido mesmo que alguma goroutine da partida entre em
+// pânico; nesse caso um relatório da falha é gravado.
+func jogar(ctx context.Context, mapaFile string, salvamento *Salvamento, config *Configuracao, diario *Diario, agente Agente, intervaloBot time.Duration) int {
+	partida, err := partidaNova(ctx, mapaFile, OpcoesPartida{Semente: time.Now().UnixNano(), Config: config, Diario: diario, Salvamento: salvamento})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err := interfaceIniciar(partida.grupo); err != nil {
+		partidaEncerrar(partida)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	jogo := partida.Jogo
 
-			// Verifica se jogador está sobre um tesouro
-			if elementoAtual.simbolo == Tesouro.simbolo {
-				select {
-				case tesouroChan <- MsgTesouro{X: jogo.PosX, Y: jogo.PosY, Aparecer: false}:
-				default:
-				}
-			}
+	// Os eventos vêm do teclado ou, se houver, do agente automático
+	lerEvento := interfaceLerEventoTeclado
+	if agente != nil {
+		lerEvento = agenteFonteEventos(agente, jogo, intervaloBot)
+	}
 
-			liberarAcessoMapa()
+	// Quando a partida termina, por um sinal, uma falha ou o encerramento, a
+	// leitura do teclado é acordada para o loop principal ou a do agente
+	// perceberem
+	context.AfterFunc(partida.grupo.Contexto(), interfaceInterromperLeitura)
+
+	// Loop principal do jogo; um pânico nele encerra a partida como o de
+	// qualquer outra goroutine dela
+	func() {
+		defer partida.grupo.capturar("principal")
+		for partida.grupo.Contexto().Err() == nil {
+			evento := lerEvento()
+			if continuar := partidaExecutar(partida, evento); !continuar {
+				return
+			}
 		}
+	}()
+
+	// Espera todas as goroutines da partida terminarem antes de devolver o
+	// terminal. O encerramento cancela a partida, então a interrupção do
+	// teclado foi pedida; se ninguém mais a leu, ela é recebida aqui.
+	falha := partidaEncerrar(partida)
+	interfaceReceberInterrupcao()
+	interfaceFinalizar()
+	if falha == nil {
+		return 0
+	}
+
+	fmt.Fprintln(os.Stderr, traduzir("falha.jogo", falha.Goroutine, falha.Valor))
+	if caminho, err := falhaGravar(falha); err != nil {
+		fmt.Fprintln(os.Stderr, traduzir("falha.erro_relatorio", err))
+		fmt.Fprint(os.Stderr, falha.Pilha)
+	} else {
+		fmt.Fprintln(os.Stderr, traduzir("falha.relatorio", caminho))
 	}
+	return 1
 }
@@ -0,0 +1,193 @@
+// mensagens.go - Histórico de mensagens exibidas ao jogador
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Gravidade indica a importância de uma mensagem
+type Gravidade int
+
+const (
+	GravidadeInfo   Gravidade = iota // acontecimentos comuns
+	GravidadeAviso                   // algo que merece atenção
+	GravidadePerigo                  // o jogador foi ferido; fica visível por um tempo mínimo
+)
+
+func (g Gravidade) String() string {
+	switch g {
+	case GravidadeAviso:
+		return "aviso"
+	case GravidadePerigo:
+		return "perigo"
+	}
+	return "info"
+}
+
+// Mensagem é uma entrada do histórico
+type Mensagem struct {
+	Quando     time.Duration // tempo da partida, sem contar as pausas
+	Gravidade  Gravidade
+	Origem     string // elemento que produziu a mensagem ("portal", "guardiao", ...)
+	ID         string // identificador do texto no catálogo do idioma
+	Texto      string // texto já traduzido
+	Repeticoes int    // vezes seguidas que a mesma mensagem foi registrada
+
+	numero int // ordem do último registro, contando as repetições
+}
+
+// Texto da mensagem com o número de repetições, como é exibido na barra
+func (m Mensagem) Resumo() string {
+	if m.Repeticoes > 1 {
+		return fmt.Sprintf("%s (x%d)", m.Texto, m.Repeticoes)
+	}
+	return m.Texto
+}
+
+// Linha completa para o histórico: instante, origem e texto
+func (m Mensagem) Formatar() string {
+	decimos := int(m.Quando / (100 * time.Millisecond))
+	return fmt.Sprintf("[%02d:%02d.%d] %s: %s", decimos/600, decimos/10%60, decimos%10, m.Origem, m.Resumo())
+}
+
+// RegistroMensagens guarda as últimas mensagens da partida. Não é protegido
+// contra acesso concorrente: só o dono do jogo o usa.
+type RegistroMensagens struct {
+	itens     []Mensagem
+	limite    int // quantidade máxima guardada; as mais antigas são descartadas
+	registros int // mensagens registradas desde o início, contando as repetições
+}
+
+// Cria um histórico que guarda até limite mensagens
+func registroNovo(limite int) *RegistroMensagens {
+	return &RegistroMensagens{limite: limite}
+}
+
+// Acrescenta uma mensagem. Uma mensagem igual à anterior só aumenta a
+// contagem de repetições, para não encher o histórico.
+func (r *RegistroMensagens) Adicionar(m Mensagem) {
+	r.registros++
+	m.Repeticoes = 1
+	m.numero = r.registros
+	if n := len(r.itens); n > 0 {
+		ultima := &r.itens[n-1]
+		if ultima.Texto == m.Texto && ultima.Origem == m.Origem && ultima.Gravidade == m.Gravidade {
+			ultima.Repeticoes++
+			ultima.Quando = m.Quando
+			ultima.numero = m.numero
+			return
+		}
+	}
+	if len(r.itens) >= r.limite {
+		r.itens = append(r.itens[:0], r.itens[len(r.itens)-r.limite+1:]...)
+	}
+	r.itens = append(r.itens, m)
+}
+
+// Recoloca as mensagens de uma partida salva, da mais antiga para a mais
+// nova, com as repetições que tinham
+func (r *RegistroMensagens) Restaurar(mensagens []Mensagem) {
+	for _, m := range mensagens {
+		repeticoes := m.Repeticoes
+		r.Adicionar(m)
+		r.itens[len(r.itens)-1].Repeticoes = max(repeticoes, 1)
+	}
+}
+
+// Mensagem mais recente
+func (r *RegistroMensagens) Ultima() (Mensagem, bool) {
+	if len(r.itens) == 0 {
+		return Mensagem{}, false
+	}
+	return r.itens[len(r.itens)-1], true
+}
+
+// Cópia de todas as mensagens guardadas, da mais antiga para a mais nova
+func (r *RegistroMensagens) Todas() []Mensagem {
+	return append([]Mensagem(nil), r.itens...)
+}
+
+// Mensagens registradas ou repetidas depois da marca indicada e a nova marca,
+// para quem acompanha o histórico aos poucos. A marca inicial é zero.
+func (r *RegistroMensagens) Desde(marca int) ([]Mensagem, int) {
+	var novas []Mensagem
+	for _, m := range r.itens {
+		if m.numero > marca {
+			novas = append(novas, m)
+		}
+	}
+	return novas, r.registros
+}
+
+// Até n mensagens para a barra de status, da mais antiga para a mais nova.
+// Mensagens de perigo registradas há menos de minimo sempre entram; as vagas
+// que sobram ficam com as mais recentes.
+func (r *RegistroMensagens) Recentes(agora time.Duration, n int, minimo time.Duration) []Mensagem {
+	escolhidas := make([]bool, len(r.itens))
+	quantas := 0
+	for i := len(r.itens) - 1; i >= 0 && quantas < n && agora-r.itens[i].Quando < minimo; i-- {
+		if r.itens[i].Gravidade >= GravidadePerigo {
+			escolhidas[i] = true
+			quantas++
+		}
+	}
+	for i := len(r.itens) - 1; i >= 0 && quantas < n; i-- {
+		if !escolhidas[i] {
+			escolhidas[i] = true
+			quantas++
+		}
+	}
+
+	var recentes []Mensagem
+	for i, m := range r.itens {
+		if escolhidas[i] {
+			recentes = append(recentes, m)
+		}
+	}
+	return recentes
+}
+
+// Registra no histórico da partida o texto indicado pelo identificador, no
+// idioma em uso (chamada pelo dono do jogo)
+func jogoMensagem(jogo *Jogo, gravidade Gravidade, origem, id string, valores ...any) {
+	m := Mensagem{
+		Quando:    jogo.relogio.Agora(),
+		Gravidade: gravidade,
+		Origem:    origem,
+		ID:        id,
+		Texto:     traduzir(id, valores...),
+	}
+	jogo.Mensagens.Adicionar(m)
+	jogo.diario.Registrar("mensagem", diarioMensagem{Gravidade: m.Gravidade.String(), Origem: m.Origem, ID: m.ID, Texto: m.Texto})
+}
+
+// Texto da mensagem mais recente (chamada pelo dono do jogo)
+func jogoStatus(jogo *Jogo) string {
+	m, _ := jogo.Mensagens.Ultima()
+	return m.Texto
+}
+
+// Abre ou fecha a tela de histórico e trata a rolagem enquanto ela está
+// aberta; retorna false se o evento não diz respeito ao histórico (chamada
+// pelo dono do jogo)
+func jogoHistorico(jogo *Jogo, ev EventoTeclado) bool {
+	if !jogo.historicoAberto {
+		if ev.Tipo != "mensagens" {
+			return false
+		}
+		jogo.historicoAberto = true
+		jogo.historicoRolagem = 0
+		return true
+	}
+
+	switch {
+	case ev.Tipo == "mensagens" || ev.Tipo == "sair":
+		jogo.historicoAberto = false
+	case ev.Tipo == "mover" && ev.Tecla == 'w':
+		jogo.historicoRolagem++ // mensagens mais antigas
+	case ev.Tipo == "mover" && ev.Tecla == 's':
+		jogo.historicoRolagem = max(jogo.historicoRolagem-1, 0)
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+// minimapa.go - Mapa em miniatura exibido quando o mapa não cabe na tela
+package main
+
+import (
+	"github.com/mattn/go-runewidth"
+	"github.com/nsf/termbox-go"
+)
+
+// Tamanho máximo do minimapa, em colunas e linhas do terminal
+const (
+	larguraMaxMinimapa = 32
+	alturaMaxMinimapa  = 10
+)
+
+// Atributos retirados das cores do tema, que no minimapa viram blocos
+const atributosCor = termbox.AttrBold | termbox.AttrBlink | termbox.AttrHidden |
+	termbox.AttrDim | termbox.AttrUnderline | termbox.AttrCursive | termbox.AttrReverse
+
+// Importância de um elemento no minimapa: quando um bloco cobre várias
+// células, aparece o elemento mais importante. Zero não é marcado.
+func minimapaPrioridade(e Elemento) int {
+	switch {
+	case elementosAmeaca[e.simbolo]:
+		return 5
+	case e.simbolo == Portal.simbolo:
+		return 4
+	case e.simbolo == Tesouro.simbolo:
+		return 3
+	case e.simbolo == Parede.simbolo:
+		return 2
+	case e.simbolo == Vegetacao.simbolo:
+		return 1
+	}
+	return 0
+}
+
+// Cor de um elemento no minimapa. Elementos desenhados com fundo próprio,
+// como as paredes, usam a cor do fundo; os demais, a do caractere.
+func minimapaCor(e Elemento) Cor {
+	estilo := temaAtual.Elemento(e)
+	cor := estilo.Frente
+	if estilo.Fundo != temaAtual.Elemento(Vazio).Fundo {
+		cor = estilo.Fundo
+	}
+	return cor &^ atributosCor
+}
+
+// Desenha o minimapa no canto superior direito da área indicada, com um
+// título na primeira linha. Cada caractere cobre um bloco de células do
+// mapa; com glifos Unicode os meios-blocos ▀ e ▄ dobram a resolução vertical.
+func desenharMinimapa(mapa [][]Elemento, pos Ponto, largura, altura int) {
+	larguraMapa := larguraDoMapa(mapa)
+	maxLargura := min(larguraMaxMinimapa, largura/3)
+	maxAltura := min(alturaMaxMinimapa, altura-1)
+	if maxLargura < 4 || maxAltura < 2 || larguraMapa == 0 {
+		return
+	}
+
+	meios := 2 // linhas do mapa em cada linha do minimapa, em blocos
+	if glifosAtuais.SoASCII() {
+		meios = 1
+	}
+	escala := max(dividirAcima(larguraMapa, maxLargura), dividirAcima(len(mapa), maxAltura*meios), 1)
+	colunas := dividirAcima(larguraMapa, escala)
+	linhas := dividirAcima(len(mapa), escala*meios)
+	x0 := largura - colunas
+
+	// Elemento mais importante do bloco (bx, by); o personagem vence todos
+	bloco := func(bx, by int) (Elemento, int) {
+		melhor, prioridade := Vazio, 0
+		for y := by * escala; y < (by+1)*escala && y < len(mapa); y++ {
+			for x := bx * escala; x < (bx+1)*escala && x < len(mapa[y]); x++ {
+				if (pos == Ponto{x, y}) {
+					return Personagem, 6
+				}
+				if p := minimapaPrioridade(mapa[y][x]); p > prioridade {
+					melhor, prioridade = mapa[y][x], p
+				}
+			}
+		}
+		return melhor, prioridade
+	}
+
+	fundo := temaAtual.Elemento(Vazio).Fundo
+	jogador := glifosAtuais.Glifo(Personagem)
+	if runewidth.RuneWidth(jogador) != 1 {
+		jogador = '@'
+	}
+	desenharTexto(x0, 0, traduzir("minimapa.titulo"), colunas, "titulo")
+	for ly := 0; ly < linhas; ly++ {
+		for bx := 0; bx < colunas; bx++ {
+			x, y := x0+bx, ly+1
+			cima, pCima := bloco(bx, ly*meios)
+			baixo, pBaixo := Vazio, 0
+			if meios == 2 {
+				baixo, pBaixo = bloco(bx, ly*2+1)
+			}
+			switch {
+			case pCima == 6 || pBaixo == 6:
+				// O personagem é marcado pela forma, não só pela cor
+				termbox.SetCell(x, y, jogador, minimapaCor(Personagem), fundo)
+			case meios == 1 && pCima > 0:
+				termbox.SetCell(x, y, ' ', fundo, minimapaCor(cima))
+			case pCima > 0 && pBaixo > 0:
+				termbox.SetCell(x, y, '▀', minimapaCor(cima), minimapaCor(baixo))
+			case pCima > 0:
+				termbox.SetCell(x, y, '▀', minimapaCor(cima), fundo)
+			case pBaixo > 0:
+				termbox.SetCell(x, y, '▄', minimapaCor(baixo), fundo)
+			default:
+				termbox.SetCell(x, y, ' ', fundo, fundo)
+			}
+		}
+	}
+}
+
+// Divisão inteira arredondada para cima
+func dividirAcima(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// Abre ou fecha o minimapa (chamada pelo dono do jogo)
+func jogoAlternarMinimapa(jogo *Jogo) {
+	jogo.minimapaVisivel = !jogo.minimapaVisivel
+	if jogo.minimapaVisivel {
+		jogoMensagem(jogo, GravidadeInfo, "jogador", "minimapa.exibido")
+	} else {
+		jogoMensagem(jogo, GravidadeInfo, "jogador", "minimapa.oculto")
+	}
+}
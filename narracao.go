@@ -0,0 +1,276 @@
+// narracao.go - Modo de narração: o jogo descrito em linhas de texto, para leitores de tela
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Distância, em passos, até a qual as ameaças são narradas depois de cada
+// ação; o comando descrever usa o dobro e lista todas
+const (
+	alcanceAmeacas = 6
+	maxAmeacas     = 3
+)
+
+// Elementos narrados como ameaças
+var elementosAmeaca = map[rune]bool{
+	Inimigo.simbolo:     true,
+	Perseguidor.simbolo: true,
+	Guardian.simbolo:    true,
+	Fantasma.simbolo:    true,
+	Armadilha.simbolo:   true,
+	Laco.simbolo:        true,
+	Alarme.simbolo:      true,
+	Teleporte.simbolo:   true,
+}
+
+// Comandos aceitos, em português e em inglês, e a ação de cada um. As
+// letras de movimento são as do WASD.
+var comandosNarracao = map[string]string{
+	"w": "cima", "norte": "cima", "cima": "cima", "north": "cima", "up": "cima",
+	"s": "baixo", "sul": "baixo", "baixo": "baixo", "south": "baixo", "down": "baixo",
+	"a": "esquerda", "oeste": "esquerda", "esquerda": "esquerda", "west": "esquerda", "left": "esquerda",
+	"d": "direita", "leste": "direita", "direita": "direita", "east": "direita", "right": "direita",
+	"e": "interagir", "interagir": "interagir", "interact": "interagir",
+	"": "esperar", "esperar": "esperar", "wait": "esperar",
+	"f": "armar", "armar": "armar", "armadilha": "armar", "trap": "armar",
+	"r": "isca", "isca": "isca", "lure": "isca",
+	"i": "inventario", "inventario": "inventario", "inventário": "inventario", "inventory": "inventario",
+	"m": "mensagens", "mensagens": "mensagens", "messages": "mensagens",
+	"salvar": "salvar", "save": "salvar",
+	"l": "descrever", "descrever": "descrever", "olhar": "descrever", "describe": "descrever", "look": "descrever",
+	"?": "ajuda", "h": "ajuda", "ajuda": "ajuda", "help": "ajuda",
+	"q": "sair", "sair": "sair", "quit": "sair", "exit": "sair",
+}
+
+// Quantas mensagens o comando mensagens repete
+const mensagensNarradas = 10
+
+// Executa o comando "narrar": lê um comando por linha da entrada e escreve
+// o que acontece na saída. O tempo do jogo só corre depois de cada ação.
+func narrarComando(args []string, entrada io.Reader, saida io.Writer) error {
+	idiomaAntecipar(args)
+	fs := flag.NewFlagSet("narrar", flag.ContinueOnError)
+	dt := fs.Duration("dt", 500*time.Millisecond, traduzir("ajuda.narrar_dt"))
+	semente := fs.Int64("semente", 0, traduzir("ajuda.narrar_semente"))
+	arquivoConfig := fs.String("config", "", traduzir("ajuda.config"))
+	idioma := fs.String("idioma", "", traduzir("ajuda.idioma"))
+	carregar := fs.String("carregar", "", traduzir("ajuda.carregar"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := idiomaEscolher(*idioma); err != nil {
+		return err
+	}
+	if *dt <= 0 {
+		return erroTraduzido("erro.dt")
+	}
+	mapaFile := "mapa.txt"
+	if fs.NArg() > 0 {
+		mapaFile = fs.Arg(0)
+	}
+	var salvamento *Salvamento
+	if *carregar != "" {
+		s, err := salvamentoLer(*carregar)
+		if err != nil {
+			return err
+		}
+		salvamento, mapaFile = s, s.Mapa
+	}
+	config, err := configCarregar(*arquivoConfig, mapaFile)
+	if err != nil {
+		return err
+	}
+	if *semente == 0 {
+		*semente = time.Now().UnixNano()
+	}
+
+	partida, err := partidaNova(context.Background(), mapaFile, OpcoesPartida{
+		Semente:     *semente,
+		PassoAPasso: true,
+		Quantum:     *dt,
+		SemTela:     true,
+		Config:      config,
+		Salvamento:  salvamento,
+	})
+	if err != nil {
+		return err
+	}
+	defer partidaEncerrar(partida)
+	jogo := partida.Jogo
+
+	fmt.Fprintln(saida, traduzir("narracao.inicio", mapaFile))
+	marca := 0
+	if salvamento != nil {
+		// As mensagens da partida salva já foram narradas quando aconteceram
+		jogoExecutar(jogo, func() { _, marca = jogo.Mensagens.Desde(0) })
+	}
+	marca = narrarMensagens(jogo, marca, saida)
+	narrarSituacao(jogo, false, saida)
+
+	scanner := bufio.NewScanner(entrada)
+	for scanner.Scan() {
+		comando := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		acao, ok := comandosNarracao[comando]
+		switch {
+		case !ok:
+			fmt.Fprintln(saida, traduzir("narracao.desconhecido", comando))
+		case acao == "ajuda":
+			fmt.Fprintln(saida, traduzir("narracao.ajuda"))
+		case acao == "descrever":
+			narrarSituacao(jogo, true, saida)
+		case acao == "mensagens":
+			var todas []Mensagem
+			jogoExecutar(jogo, func() { todas = jogo.Mensagens.Todas() })
+			for _, m := range todas[max(len(todas)-mensagensNarradas, 0):] {
+				fmt.Fprintln(saida, m.Formatar())
+			}
+		default:
+			if !partidaExecutar(partida, acoesTeclado[acao]) {
+				return nil
+			}
+			partidaAvancar(partida, *dt)
+			marca = narrarMensagens(jogo, marca, saida)
+
+			terminou, pontos := false, 0
+			jogoExecutar(jogo, func() { terminou, pontos = jogoTerminou(jogo), jogo.Pontos })
+			if terminou {
+				fmt.Fprintln(saida, traduzir("narracao.fim", pontos))
+				return nil
+			}
+			narrarSituacao(jogo, false, saida)
+		}
+	}
+	return scanner.Err()
+}
+
+// Escreve as mensagens registradas depois da marca, uma vez cada, sem a
+// contagem de repetições; as de perigo são anunciadas como tal. Retorna a
+// nova marca.
+func narrarMensagens(jogo *Jogo, marca int, saida io.Writer) int {
+	var novas []Mensagem
+	jogoExecutar(jogo, func() { novas, marca = jogo.Mensagens.Desde(marca) })
+
+	for _, m := range novas {
+		switch {
+		case m.ID == "jogo.fim":
+			// o fim de jogo é narrado à parte, sem a tecla de sair
+		case m.Gravidade >= GravidadePerigo:
+			fmt.Fprintln(saida, traduzir("narracao.perigo", m.Texto))
+		default:
+			fmt.Fprintln(saida, m.Texto)
+		}
+	}
+	return marca
+}
+
+// Descreve o entorno do personagem: o que há em cada direção, o tesouro
+// mais próximo e as ameaças ao alcance. A descrição completa também diz a
+// posição, o placar e o que está sob o personagem.
+func narrarSituacao(jogo *Jogo, completa bool, saida io.Writer) {
+	// O texto é montado pelo dono do jogo e escrito depois
+	var texto strings.Builder
+	jogoExecutar(jogo, func() { narrarEntorno(jogo, completa, &texto) })
+	io.WriteString(saida, texto.String())
+}
+
+// Escreve a descrição do entorno (chamada pelo dono do jogo)
+func narrarEntorno(jogo *Jogo, completa bool, saida io.Writer) {
+	pos := Ponto{jogo.PosX, jogo.PosY}
+	if completa {
+		fmt.Fprintln(saida, traduzir("narracao.posicao", pos.X, pos.Y, jogo.Vida, jogo.Pontos, jogo.Stats.TesourosColetados))
+		sob := jogo.Mapa[pos.Y][pos.X]
+		if sob.simbolo != Vazio.simbolo {
+			fmt.Fprintln(saida, traduzir("narracao.sob", traduzir(descricoesElementos[sob.simbolo])))
+		}
+	}
+
+	// O que há em cada direção; fora do mapa é o limite
+	var direcoes []string
+	vizinhos := personagemVizinhos(jogo)
+	for _, d := range direcoesVizinhas {
+		descricao := traduzir("narracao.limite")
+		for _, v := range vizinhos {
+			if v.Direcao == d.nome {
+				descricao = personagemDescrever(jogo, v.Pos)
+			}
+		}
+		direcoes = append(direcoes, traduzir("narracao.direcao", traduzir("narracao."+d.nome), descricao))
+	}
+	fmt.Fprintln(saida, strings.Join(direcoes, " "))
+
+	// Tesouro mais próximo, em passos
+	var tesouro *Ponto
+	for y, linha := range jogo.Mapa {
+		for x, e := range linha {
+			p := Ponto{x, y}
+			if e.simbolo == Tesouro.simbolo && (tesouro == nil || heuristicaManhattan(pos, p) < heuristicaManhattan(pos, *tesouro)) {
+				tesouro = &p
+			}
+		}
+	}
+	if tesouro != nil {
+		fmt.Fprintln(saida, traduzir("narracao.tesouro", narrarRumo(pos, *tesouro)))
+	} else {
+		fmt.Fprintln(saida, traduzir("narracao.sem_tesouro"))
+	}
+
+	// Ameaças ao alcance, das mais próximas para as mais distantes
+	alcance, limite := alcanceAmeacas, maxAmeacas
+	if completa {
+		alcance, limite = 2*alcanceAmeacas, -1
+	}
+	var ameacas []Ponto
+	for y := pos.Y - alcance; y <= pos.Y+alcance; y++ {
+		for x := pos.X - alcance; x <= pos.X+alcance; x++ {
+			p := Ponto{x, y}
+			if p != pos && dentroDoMapa(jogo.Mapa, p) && heuristicaManhattan(pos, p) <= alcance && elementosAmeaca[jogo.Mapa[y][x].simbolo] {
+				ameacas = append(ameacas, p)
+			}
+		}
+	}
+	sort.SliceStable(ameacas, func(i, j int) bool {
+		return heuristicaManhattan(pos, ameacas[i]) < heuristicaManhattan(pos, ameacas[j])
+	})
+	if limite >= 0 && len(ameacas) > limite {
+		ameacas = ameacas[:limite]
+	}
+	if len(ameacas) == 0 {
+		fmt.Fprintln(saida, traduzir("narracao.sem_ameacas"))
+		return
+	}
+	var textos []string
+	for _, p := range ameacas {
+		textos = append(textos, traduzir("narracao.ameaca", personagemDescrever(jogo, p), narrarRumo(pos, p)))
+	}
+	fmt.Fprintln(saida, traduzir("narracao.ameacas", strings.Join(textos, "; ")))
+}
+
+// Caminho de um ponto a outro em passos por eixo, como "3 ao norte e 2 a leste"
+func narrarRumo(de, ate Ponto) string {
+	var partes []string
+	dx, dy := ate.X-de.X, ate.Y-de.Y
+	switch {
+	case dy < 0:
+		partes = append(partes, traduzir("narracao.rumo.norte", -dy))
+	case dy > 0:
+		partes = append(partes, traduzir("narracao.rumo.sul", dy))
+	}
+	switch {
+	case dx < 0:
+		partes = append(partes, traduzir("narracao.rumo.oeste", -dx))
+	case dx > 0:
+		partes = append(partes, traduzir("narracao.rumo.leste", dx))
+	}
+	if len(partes) == 0 {
+		return traduzir("narracao.rumo.aqui")
+	}
+	return strings.Join(partes, traduzir("narracao.rumo.e"))
+}
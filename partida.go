@@ -0,0 +1,216 @@
+// partida.go - Criação de uma partida: carrega o mapa e liga os elementos concorrentes
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// Partida reúne um jogo em andamento e o grupo das suas goroutines
+type Partida struct {
+	Jogo *Jogo
+
+	grupo *Grupo
+}
+
+// OpcoesPartida controla como a partida é executada
+type OpcoesPartida struct {
+	Semente     int64         // semente dos geradores aleatórios dos elementos
+	PassoAPasso bool          // elementos só avançam quando partidaAvancar é chamada
+	Quantum     time.Duration // menor passo de tempo no modo passo a passo
+	SemTela     bool          // não desenha nada no terminal
+	Config      *Configuracao // parâmetros dos elementos (nil usa os valores padrão)
+	Diario      *Diario       // diário onde a partida é gravada (nil não grava)
+	Salvamento  *Salvamento   // partida salva a continuar, no lugar do mapa (nil começa do mapa)
+}
+
+// Carrega o mapa e inicia todos os elementos concorrentes de uma nova
+// partida. Com um salvamento nas opções a partida continua dele, com a
+// semente e o mapa dele. Cancelar o contexto encerra a partida como
+// partidaEncerrar.
+func partidaNova(ctx context.Context, mapaFile string, opcoes OpcoesPartida) (*Partida, error) {
+	if opcoes.Config == nil {
+		opcoes.Config = configPadrao()
+	}
+	salvo := opcoes.Salvamento
+	if salvo != nil {
+		mapaFile, opcoes.Semente = salvo.Mapa, salvo.Semente
+	}
+	relogio := relogioTempoReal(opcoes.Config.Relogio.Passo.Tempo())
+	if opcoes.PassoAPasso {
+		if opcoes.Quantum <= 0 {
+			opcoes.Quantum = 100 * time.Millisecond
+		}
+		relogio = relogioPassoAPasso(opcoes.Quantum)
+	}
+
+	jogo := jogoNovo(opcoes.Semente, relogio, opcoes.Config)
+	jogo.semTela = opcoes.SemTela
+	jogo.mapa = mapaFile
+	if salvo != nil {
+		relogio.comecarEm(salvo.Agora)
+		if err := jogoRestaurar(&jogo, salvo); err != nil {
+			return nil, err
+		}
+	} else if err := jogoCarregarMapa(mapaFile, &jogo); err != nil {
+		return nil, err
+	}
+	jogoLigarDiario(&jogo, opcoes.Diario)
+	jogo.diario.Registrar("inicio", diarioPartida{
+		Mapa: mapaFile, Semente: opcoes.Semente, Jogador: Ponto{jogo.PosX, jogo.PosY}, Vida: jogo.Vida,
+	})
+
+	// Os elementos se comunicam pelo barramento de eventos do jogo e pedem
+	// as alterações no estado ao dono do jogo, a única goroutine que o altera
+	p := &Partida{Jogo: &jogo, grupo: grupoNovo(ctx)}
+	p.Jogo.grupo = p.grupo
+	ctx = p.grupo.Contexto()
+	jogoIniciarDono(ctx, p.Jogo)
+
+	// Inicia todos os elementos concorrentes
+	if len(jogo.Rotas) == 0 {
+		iniciarInimigoPatrulha(ctx, p.Jogo, jogo.config.Patrulha.Inicio.X, jogo.config.Patrulha.Inicio.Y)
+	}
+	for _, rota := range jogo.Rotas {
+		for i := 0; i < rota.Inimigos; i++ {
+			iniciarInimigoRota(ctx, p.Jogo, rota, i)
+		}
+	}
+	iniciarPortal(ctx, p.Jogo)
+	iniciarArmadilha(ctx, p.Jogo)
+	iniciarFantasma(ctx, p.Jogo)
+	iniciarTesouro(ctx, p.Jogo)
+	iniciarGuardian(ctx, p.Jogo)
+	iniciarEnxame(ctx, p.Jogo)
+
+	// Inicia o sistema de controle central que coordena os elementos
+	iniciarControleCentral(ctx, p.Jogo)
+
+	// Comportamentos registrados por outros arquivos, como as interações automáticas
+	iniciarComportamentos(ctx, p.Jogo)
+
+	// Em tempo real o agendador dá os passos da simulação, e a tela é
+	// desenhada à parte, na sua própria taxa
+	if !opcoes.PassoAPasso {
+		p.grupo.Iniciar("agendador", func() { relogio.Agendar(ctx) })
+	}
+	if !opcoes.SemTela {
+		interfaceIniciarQuadros(ctx, p.Jogo)
+	}
+
+	return p, nil
+}
+
+// Executa uma ação do jogador; retorna false quando o jogador pede para sair.
+// Enquanto a partida está pausada só é possível retomar, inspecionar, abrir o
+// minimapa ou o painel de depuração, salvar ou sair.
+// Com o histórico de mensagens aberto as teclas só rolam e fecham o histórico.
+// A ação é uma intenção aplicada de uma vez pelo dono do jogo.
+func partidaExecutar(p *Partida, ev EventoTeclado) bool {
+	continuar := false
+	jogoExecutar(p.Jogo, func() { continuar = partidaAplicar(p.Jogo, ev) })
+	return continuar
+}
+
+// Aplica uma ação do jogador (chamada pelo dono do jogo)
+func partidaAplicar(jogo *Jogo, ev EventoTeclado) bool {
+	if jogoHistorico(jogo, ev) {
+		return true
+	}
+	relogio := jogo.relogio
+	if ev.Tipo == "pausar" {
+		if relogio.Pausado() {
+			relogio.Retomar()
+			jogoMensagem(jogo, GravidadeInfo, "jogo", "jogo.retomado")
+		} else {
+			relogio.Pausar()
+			jogoMensagem(jogo, GravidadeInfo, "jogo", "jogo.pausado", teclasAtuais.Rotulo("pausar"))
+		}
+		return true
+	}
+	if relogio.Pausado() && ev.Tipo != "sair" && ev.Tipo != "inspecionar" && ev.Tipo != "minimapa" && ev.Tipo != "depurar" && ev.Tipo != "salvar" {
+		return true
+	}
+	return personagemExecutarAcao(ev, jogo)
+}
+
+// Avança o tempo da partida; só tem efeito no modo passo a passo
+func partidaAvancar(p *Partida, d time.Duration) {
+	if p.Jogo.relogio.passoAPasso {
+		p.Jogo.relogio.Avancar(d)
+	}
+}
+
+// Encerra a partida: cancela o contexto e espera as goroutines terminarem.
+// Retorna a falha que encerrou a partida, se alguma goroutine entrou em
+// pânico; ela traz o estado da partida para o relatório.
+func partidaEncerrar(p *Partida) *Falha {
+	jogo := p.Jogo
+	jogoExecutar(jogo, func() {
+		stats := jogo.Stats
+		jogo.diario.Registrar("fim", diarioPartida{Jogador: Ponto{jogo.PosX, jogo.PosY}, Vida: jogo.Vida, Pontos: jogo.Pontos, Stats: &stats})
+	})
+	p.grupo.Cancelar()
+	pendentes := p.grupo.Esperar(limiteEncerramento)
+	if len(pendentes) > 0 {
+		jogo.diario.Registrar("encerramento", map[string][]string{"pendentes": pendentes})
+	}
+
+	falha := p.grupo.Falha()
+	if falha != nil {
+		falha.Pendentes = pendentes
+		if len(pendentes) == 0 {
+			falha.Estado = jogoDespejo(jogo)
+		}
+		jogo.diario.Registrar("falha", falha)
+	}
+	return falha
+}
+
+func init() {
+	registrarComportamento("interacoes", gerenciarInteracoes)
+}
+
+// Função para gerenciar interações automáticas baseadas na posição do jogador
+func gerenciarInteracoes(ctx context.Context, jogo *Jogo) {
+	const periodo = 100 * time.Millisecond
+	ticker := jogo.relogio.pulsar(periodo)
+	jogo.grupo.Iniciar("interacoes", func() {
+		defer ticker.Parar()
+
+		// Quantos pulsos seguidos o jogador está sobre um portal
+		sobrePortal := 0
+		pulsosAutoUso := max(int(jogo.config.Portal.AutoUso.Tempo()/periodo), 1)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// Verifica se jogador está sobre um portal
+				var elementoAtual Elemento
+				var pos Ponto
+				jogoExecutar(jogo, func() {
+					elementoAtual = jogo.Mapa[jogo.PosY][jogo.PosX]
+					pos = Ponto{jogo.PosX, jogo.PosY}
+				})
+
+				if elementoAtual.simbolo == Portal.simbolo {
+					// Auto-uso do portal depois de algum tempo parado sobre ele
+					sobrePortal++
+					if sobrePortal == pulsosAutoUso {
+						jogoPublicar(jogo, JogadorInteragiu{Pos: pos, Simbolo: Portal.simbolo})
+					}
+				} else {
+					sobrePortal = 0
+				}
+
+				// Verifica se jogador está sobre um tesouro
+				if elementoAtual.simbolo == Tesouro.simbolo {
+					jogoPublicar(jogo, JogadorInteragiu{Pos: pos, Simbolo: Tesouro.simbolo})
+				}
+				ticker.Concluir()
+			}
+		}
+	})
+}
@@ -0,0 +1,155 @@
+// patrulha.go - Rotas de patrulha definidas no arquivo do mapa
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Separador entre o cabeçalho opcional e as linhas do mapa
+const separadorCabecalho = "---"
+
+// Nome da rota formada pelos marcadores numéricos (0 a 9) desenhados no mapa
+const rotaMarcadores = "marcadores"
+
+// ModoRota define como a patrulha escolhe o próximo ponto da rota
+type ModoRota string
+
+const (
+	RotaCircular  ModoRota = "loop"      // volta ao primeiro ponto depois do último
+	RotaVaiVem    ModoRota = "vaivem"    // percorre a rota de ida e volta
+	RotaAleatoria ModoRota = "aleatorio" // sorteia o próximo ponto a cada chegada
+)
+
+// RotaPatrulha é uma lista de pontos percorrida por um ou mais inimigos
+type RotaPatrulha struct {
+	Nome      string
+	Pontos    []Ponto
+	Modo      ModoRota
+	Intervalo time.Duration // tempo entre dois passos (0 usa patrulha.intervalo da configuração)
+	Pausa     time.Duration // tempo parado em cada ponto
+	Inimigos  int           // quantos inimigos percorrem a rota
+}
+
+// Rota com os valores padrão, usada antes de aplicar as opções do cabeçalho
+func rotaNova(nome string) *RotaPatrulha {
+	return &RotaPatrulha{
+		Nome:     nome,
+		Modo:     RotaCircular,
+		Inimigos: 1,
+	}
+}
+
+// Interpreta uma linha do cabeçalho do mapa. Formato:
+//
+//	rota <nome> [modo=loop|vaivem|aleatorio] [intervalo=800ms] [pausa=1s] [inimigos=1] [x,y ...]
+//
+// A rota "marcadores" recebe os pontos dos dígitos 0 a 9 desenhados no mapa,
+// na ordem numérica, e por isso pode ser declarada sem pontos.
+func rotaInterpretar(linha string) (*RotaPatrulha, error) {
+	campos := strings.Fields(linha)
+	if len(campos) < 2 || campos[0] != "rota" {
+		return nil, erroTraduzido("erro.rota_diretiva", linha)
+	}
+
+	rota := rotaNova(campos[1])
+	for _, campo := range campos[2:] {
+		if chave, valor, ok := strings.Cut(campo, "="); ok {
+			if err := rotaOpcao(rota, chave, valor); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		xs, ys, ok := strings.Cut(campo, ",")
+		x, errX := strconv.Atoi(xs)
+		y, errY := strconv.Atoi(ys)
+		if !ok || errX != nil || errY != nil {
+			return nil, erroTraduzido("erro.rota_ponto", rota.Nome, campo)
+		}
+		rota.Pontos = append(rota.Pontos, Ponto{x, y})
+	}
+	return rota, nil
+}
+
+// Aplica uma opção chave=valor à rota
+func rotaOpcao(rota *RotaPatrulha, chave, valor string) error {
+	var err error
+	switch chave {
+	case "modo":
+		rota.Modo = ModoRota(valor)
+		if rota.Modo != RotaCircular && rota.Modo != RotaVaiVem && rota.Modo != RotaAleatoria {
+			return erroTraduzido("erro.rota_modo", rota.Nome, valor)
+		}
+	case "intervalo":
+		rota.Intervalo, err = time.ParseDuration(valor)
+		if err == nil && rota.Intervalo <= 0 {
+			err = erroTraduzido("erro.rota_positivo")
+		}
+	case "pausa":
+		rota.Pausa, err = time.ParseDuration(valor)
+	case "inimigos":
+		rota.Inimigos, err = strconv.Atoi(valor)
+	default:
+		return erroTraduzido("erro.rota_opcao", rota.Nome, chave)
+	}
+	if err != nil {
+		return erroTraduzido("erro.rota_valor", chave, rota.Nome, err)
+	}
+	return nil
+}
+
+// Escreve a rota no formato do cabeçalho, o inverso de rotaInterpretar
+func rotaFormatar(rota *RotaPatrulha) string {
+	campos := []string{"rota", rota.Nome, "modo=" + string(rota.Modo)}
+	if rota.Intervalo > 0 {
+		campos = append(campos, "intervalo="+rota.Intervalo.String())
+	}
+	if rota.Pausa > 0 {
+		campos = append(campos, "pausa="+rota.Pausa.String())
+	}
+	campos = append(campos, "inimigos="+strconv.Itoa(rota.Inimigos))
+	for _, p := range rota.Pontos {
+		campos = append(campos, fmt.Sprintf("%d,%d", p.X, p.Y))
+	}
+	return strings.Join(campos, " ")
+}
+
+// Confere se os pontos de todas as rotas estão dentro do mapa e fora das paredes
+func rotasValidar(rotas []*RotaPatrulha, mapa [][]Elemento) error {
+	for _, rota := range rotas {
+		if len(rota.Pontos) == 0 {
+			return erroTraduzido("erro.rota_vazia", rota.Nome)
+		}
+		for _, p := range rota.Pontos {
+			if !dentroDoMapa(mapa, p) || mapa[p.Y][p.X].tangivel {
+				return erroTraduzido("erro.rota_fora", p.X, p.Y, rota.Nome)
+			}
+		}
+	}
+	return nil
+}
+
+// Escolhe o índice do próximo ponto da rota. sentido vale 1 ou -1 e só é
+// usado no modo vaivem, onde é invertido nas pontas.
+func rotaProximoPonto(rota *RotaPatrulha, atual, sentido int, sortear func(n int) int) (int, int) {
+	n := len(rota.Pontos)
+	if n == 1 {
+		return 0, sentido
+	}
+	switch rota.Modo {
+	case RotaVaiVem:
+		if atual+sentido < 0 || atual+sentido >= n {
+			sentido = -sentido
+		}
+		return atual + sentido, sentido
+	case RotaAleatoria:
+		prox := sortear(n - 1)
+		if prox >= atual {
+			prox++ // nunca sorteia o ponto onde já está
+		}
+		return prox, sentido
+	}
+	return (atual + 1) % n, sentido
+}
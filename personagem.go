@@ -1,9 +1,10 @@
 // personagem.go - Funções para movimentação e ações do personagem com interações expandidas
 package main
 
-import "fmt"
+import "context"
 
 // Atualiza a posição do personagem com base na tecla pressionada (WASD)
+// (chamada pelo dono do jogo)
 func personagemMover(tecla rune, jogo *Jogo) {
 	dx, dy := 0, 0
 	switch tecla {
@@ -17,120 +18,372 @@ func personagemMover(tecla rune, jogo *Jogo) {
 		dx = 1 // Move para a direita
 	}
 
+	// Mover pelo teclado interrompe a caminhada escolhida com o mouse
+	jogo.caminhoJogador = nil
+	personagemPasso(jogo, dx, dy)
+}
+
+// Dá um passo na direção (dx, dy), informando o que bloqueou o caminho se não
+// for possível (chamada pelo dono do jogo)
+func personagemPasso(jogo *Jogo, dx, dy int) bool {
 	nx, ny := jogo.PosX+dx, jogo.PosY+dy
+	if dx != 0 || dy != 0 {
+		jogo.direcao = Ponto{dx, dy}
+	}
 
-	// Usa exclusão mútua para proteger o acesso ao mapa
-	obterAcessoMapa()
-	defer liberarAcessoMapa()
+	// O laço segura o personagem no lugar até soltar
+	if jogo.relogio.Agora() < jogo.presoAte {
+		jogoMensagem(jogo, GravidadeAviso, "jogador", "jogador.preso")
+		return false
+	}
 
-	// Verifica se o movimento é permitido e realiza a movimentação
-	if jogoPodeMoverPara(jogo, nx, ny) {
+	// Verifica se o movimento é permitido e realiza a movimentação. As
+	// armadilhas bloqueiam os outros elementos, mas o personagem pode pisar
+	// nelas; quem as dispara é o sistema de armadilhas, ao saber do passo.
+	if dentroDoMapa(jogo.Mapa, Ponto{nx, ny}) && passavelJogador(nx, ny, jogo.Mapa[ny][nx]) {
 		// Verifica interações especiais antes de mover
 		elementoDestino := jogo.Mapa[ny][nx]
 
 		switch elementoDestino.simbolo {
-		case Armadilha.simbolo:
-			jogo.StatusMsg = "Você pisou numa armadilha! Cuidado!"
+		case Armadilha.simbolo, Laco.simbolo, Alarme.simbolo, Teleporte.simbolo:
+			jogoMensagem(jogo, GravidadeAviso, "jogador", "jogador.armadilha")
 		case Fantasma.simbolo:
-			jogo.StatusMsg = "Você passou através do fantasma... arrepiante!"
+			jogoMensagem(jogo, GravidadeInfo, "jogador", "jogador.fantasma")
 		}
 
-		jogoMoverElemento(jogo, jogo.PosX, jogo.PosY, dx, dy)
+		// O personagem é desenhado por cima do mapa, então a célula de destino
+		// permanece intacta e continua visível para portais e tesouros
+		jogo.UltimoVisitado = elementoDestino
+		jogoPublicar(jogo, JogadorMoveu{De: Ponto{jogo.PosX, jogo.PosY}, Para: Ponto{nx, ny}})
 		jogo.PosX, jogo.PosY = nx, ny
-	} else {
-		// Verifica o que está bloqueando o movimento
-		if nx >= 0 && nx < len(jogo.Mapa[0]) && ny >= 0 && ny < len(jogo.Mapa) {
-			elementoBloqueador := jogo.Mapa[ny][nx]
-			switch elementoBloqueador.simbolo {
-			case Parede.simbolo:
-				jogo.StatusMsg = "Você bateu na parede!"
-			case Inimigo.simbolo:
-				jogo.StatusMsg = "Um inimigo está bloqueando o caminho!"
-			case Guardian.simbolo:
-				jogo.StatusMsg = "O guardião não deixa você passar!"
-			default:
-				jogo.StatusMsg = "Caminho bloqueado!"
-			}
+		return true
+	}
+
+	// Verifica o que está bloqueando o movimento
+	if dentroDoMapa(jogo.Mapa, Ponto{nx, ny}) {
+		elementoBloqueador := jogo.Mapa[ny][nx]
+		switch elementoBloqueador.simbolo {
+		case Parede.simbolo:
+			jogoMensagem(jogo, GravidadeInfo, "jogador", "jogador.parede")
+		case Inimigo.simbolo:
+			jogoMensagem(jogo, GravidadeInfo, "jogador", "jogador.bloqueio_inimigo")
+		case Guardian.simbolo:
+			jogoMensagem(jogo, GravidadeInfo, "jogador", "jogador.bloqueio_guardiao")
+		default:
+			jogoMensagem(jogo, GravidadeInfo, "jogador", "jogador.bloqueado")
 		}
 	}
+	return false
+}
+
+// Traduz uma célula da tela para a célula do mapa desenhada nela, usando o
+// deslocamento da janela (chamada pelo dono do jogo)
+func personagemCelulaTela(jogo *Jogo, x, y int) (Ponto, bool) {
+	j := jogo.janela
+	x /= max(j.Celula, 1) // colunas do terminal para células do mapa
+	if x < 0 || y < 0 || x >= j.Largura || y >= j.Altura {
+		return Ponto{}, false
+	}
+	p := Ponto{j.X + x, j.Y + y}
+	return p, dentroDoMapa(jogo.Mapa, p)
+}
+
+// Calcula o caminho até a célula clicada; o personagem o percorre um passo
+// por pulso da caminhada (chamada pelo dono do jogo)
+func personagemCaminharAte(jogo *Jogo, x, y int) {
+	destino, ok := personagemCelulaTela(jogo, x, y)
+	if !ok {
+		return // clique fora do mapa, como na barra de status
+	}
+	origem := Ponto{jogo.PosX, jogo.PosY}
+	jogo.caminhoJogador = nil
+	switch {
+	case destino == origem:
+		jogoMensagem(jogo, GravidadeInfo, "jogador", "caminhada.aqui")
+		return
+	case !passavelJogador(destino.X, destino.Y, jogo.Mapa[destino.Y][destino.X]):
+		jogoMensagem(jogo, GravidadeInfo, "jogador", "caminhada.intransitavel", destino.X, destino.Y)
+		return
+	}
+
+	caminho := buscadorNovo(passavelJogador).Caminho(jogo.Mapa, origem, destino)
+	if caminho == nil {
+		jogoMensagem(jogo, GravidadeInfo, "jogador", "caminhada.sem_caminho", destino.X, destino.Y)
+		return
+	}
+	jogo.caminhoJogador = caminho
+	jogoMensagem(jogo, GravidadeInfo, "jogador", "caminhada.iniciada", destino.X, destino.Y)
+}
+
+// Texto que descreve cada elemento ao inspecionar uma célula
+var descricoesElementos = map[rune]string{
+	Vazio.simbolo:       "inspecao.vazio",
+	Parede.simbolo:      "inspecao.parede",
+	Vegetacao.simbolo:   "inspecao.vegetacao",
+	Inimigo.simbolo:     "inspecao.inimigo",
+	Perseguidor.simbolo: "inspecao.perseguidor",
+	Portal.simbolo:      "inspecao.portal",
+	Armadilha.simbolo:   "inspecao.armadilha",
+	Laco.simbolo:        "inspecao.laco",
+	Alarme.simbolo:      "inspecao.alarme",
+	Teleporte.simbolo:   "inspecao.teleporte",
+
+	ArmadilhaGasta.simbolo:   "inspecao.armadilha_gasta",
+	ArmadilhaJogador.simbolo: "inspecao.armadilha_jogador",
+	Isca.simbolo:             "inspecao.isca",
+	Fantasma.simbolo:         "inspecao.fantasma",
+	Tesouro.simbolo:          "inspecao.tesouro",
+}
+
+// Mostra na barra de status o que há na célula clicada (chamada pelo dono
+// do jogo)
+func personagemInspecionar(jogo *Jogo, x, y int) {
+	p, ok := personagemCelulaTela(jogo, x, y)
+	if !ok {
+		return
+	}
+	jogoMensagem(jogo, GravidadeInfo, "jogador", "inspecao.celula", p.X, p.Y, personagemDescrever(jogo, p))
+}
+
+// Descrição do que há numa célula do mapa, no idioma em uso (chamada pelo
+// dono do jogo)
+func personagemDescrever(jogo *Jogo, p Ponto) string {
+	elemento := jogo.Mapa[p.Y][p.X]
+	id, conhecido := descricoesElementos[elemento.simbolo]
+	switch {
+	case p == Ponto{jogo.PosX, jogo.PosY}:
+		return traduzir("inspecao.voce")
+	case elemento.simbolo == Guardian.simbolo:
+		return traduzir("inspecao.guardiao", jogo.EstadoGuardiao.Nome())
+	case !conhecido:
+		return traduzir("inspecao.desconhecido")
+	}
+	return traduzir(id)
+}
+
+func init() {
+	registrarComportamento("caminhada", personagemCaminhar)
+}
+
+// Leva o personagem pelo caminho escolhido com o mouse, um passo por pulso.
+// A caminhada para se algo bloquear o caminho ou a partida terminar.
+func personagemCaminhar(ctx context.Context, jogo *Jogo) {
+	ticker := jogo.relogio.pulsar(jogo.config.Jogador.IntervaloCaminhada.Tempo())
+	jogo.grupo.Iniciar("caminhada", func() {
+		defer ticker.Parar()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				andou := false
+				jogoExecutar(jogo, func() {
+					if len(jogo.caminhoJogador) > 0 && !jogoTerminou(jogo) {
+						prox := jogo.caminhoJogador[0]
+						andou = personagemPasso(jogo, prox.X-jogo.PosX, prox.Y-jogo.PosY)
+					}
+					if andou {
+						jogo.caminhoJogador = jogo.caminhoJogador[1:]
+					} else {
+						jogo.caminhoJogador = nil // o status explica o que bloqueou
+					}
+					if len(jogo.caminhoJogador) > 0 {
+						jogoInformarEstado(jogo, "caminhada", Ponto{jogo.PosX, jogo.PosY}, "estado.caminhada_faltam", len(jogo.caminhoJogador))
+					} else {
+						jogoInformarEstado(jogo, "caminhada", semPosicao, "estado.caminhada_parada")
+					}
+				})
+				ticker.Concluir()
+			}
+		}
+	})
 }
 
 // Define o que ocorre quando o jogador pressiona a tecla de interação
-func personagemInteragir(jogo *Jogo, portalChan chan MsgPortal, tesouroChan chan MsgTesouro) {
-	obterAcessoMapa()
+// (chamada pelo dono do jogo)
+func personagemInteragir(jogo *Jogo) {
 	elementoAtual := jogo.Mapa[jogo.PosY][jogo.PosX]
-	liberarAcessoMapa()
 
 	// Verifica interações baseadas no elemento atual
 	switch elementoAtual.simbolo {
 	case Portal.simbolo:
-		jogo.StatusMsg = "Usando portal..."
-		select {
-		case portalChan <- MsgPortal{X: jogo.PosX, Y: jogo.PosY, Cmd: "usar"}:
-		default:
-			jogo.StatusMsg = "Portal não responde..."
-		}
+		personagemAvisar(jogo, "interacao.portal")
+		jogoPublicar(jogo, JogadorInteragiu{Pos: Ponto{jogo.PosX, jogo.PosY}, Simbolo: Portal.simbolo})
 	case Tesouro.simbolo:
-		jogo.StatusMsg = "Coletando tesouro!"
-		select {
-		case tesouroChan <- MsgTesouro{X: jogo.PosX, Y: jogo.PosY, Aparecer: false}:
-		default:
-			jogo.StatusMsg = "Não foi possível coletar o tesouro"
-		}
+		personagemAvisar(jogo, "interacao.tesouro")
+		jogoPublicar(jogo, JogadorInteragiu{Pos: Ponto{jogo.PosX, jogo.PosY}, Simbolo: Tesouro.simbolo})
 	default:
+		// Desarma a armadilha sob o personagem ou ao lado dele
+		if p, ok := personagemArmadilhaProxima(jogo); ok {
+			personagemAvisar(jogo, "interacao.armadilha")
+			jogoPublicar(jogo, PedidoArmadilha{Pos: p})
+			return
+		}
+
+		// Procura armadilhas escondidas em volta
+		if n := armadilhaProcurar(jogo); n > 0 {
+			jogoMensagem(jogo, GravidadeAviso, "jogador", "interacao.armadilhas_encontradas", n)
+			return
+		}
+
 		// Verifica elementos adjacentes para interação
 		interagiu := false
 
 		// Verifica as 4 direções adjacentes
-		direccoes := [][]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}}
-		for _, dir := range direccoes {
-			x, y := jogo.PosX+dir[0], jogo.PosY+dir[1]
-			if x >= 0 && x < len(jogo.Mapa[0]) && y >= 0 && y < len(jogo.Mapa) {
-				obterAcessoMapa()
-				elemento := jogo.Mapa[y][x]
-				liberarAcessoMapa()
-
-				switch elemento.simbolo {
-				case Vegetacao.simbolo:
-					if !interagiu {
-						jogo.StatusMsg = "Você examina a vegetação... nada interessante"
-						interagiu = true
-					}
-				case Parede.simbolo:
-					if !interagiu {
-						jogo.StatusMsg = "Você toca na parede... é sólida"
-						interagiu = true
-					}
-				case Inimigo.simbolo:
-					if !interagiu {
-						jogo.StatusMsg = "O inimigo te olha ameaçadoramente!"
-						interagiu = true
-					}
-				case Guardian.simbolo:
-					if !interagiu {
-						jogo.StatusMsg = "O guardião permanece imóvel... por enquanto"
-						interagiu = true
+		for _, v := range personagemVizinhos(jogo) {
+			switch v.Elemento.simbolo {
+			case Vegetacao.simbolo:
+				if !interagiu {
+					personagemAvisar(jogo, "interacao.vegetacao")
+					interagiu = true
+				}
+			case Parede.simbolo:
+				if !interagiu {
+					personagemAvisar(jogo, "interacao.parede")
+					interagiu = true
+				}
+			case Inimigo.simbolo:
+				if !interagiu {
+					personagemAvisar(jogo, "interacao.inimigo")
+					interagiu = true
+				}
+			case Guardian.simbolo:
+				if !interagiu {
+					if jogo.EstadoGuardiao == GuardiaoDormindo {
+						personagemAvisar(jogo, "interacao.guardiao_dormindo")
+					} else {
+						personagemAvisar(jogo, "interacao.guardiao_acordado")
 					}
+					interagiu = true
 				}
 			}
 		}
 
 		if !interagiu {
-			jogo.StatusMsg = fmt.Sprintf("Interagindo em (%d, %d) - nada acontece", jogo.PosX, jogo.PosY)
+			personagemAvisar(jogo, "interacao.nada", jogo.PosX, jogo.PosY)
 		}
 	}
 }
 
-// Processa o evento do teclado e executa a ação correspondente
-func personagemExecutarAcao(ev EventoTeclado, jogo *Jogo, portalChan chan MsgPortal, tesouroChan chan MsgTesouro) bool {
+// Posição da armadilha visível sob o personagem ou numa das quatro células
+// ao lado dele (chamada pelo dono do jogo)
+func personagemArmadilhaProxima(jogo *Jogo) (Ponto, bool) {
+	if elementoArmadilha(jogo.Mapa[jogo.PosY][jogo.PosX]) {
+		return Ponto{jogo.PosX, jogo.PosY}, true
+	}
+	for _, v := range personagemVizinhos(jogo) {
+		if elementoArmadilha(v.Elemento) {
+			return v.Pos, true
+		}
+	}
+	return Ponto{}, false
+}
+
+// Coloca uma armadilha ou isca do inventário na célula para onde o
+// personagem andou por último ou, se ela não servir, na primeira vizinha
+// livre. Quem a põe no mapa é o sistema de armadilhas, que devolve o item ao
+// inventário se a célula não estiver mais livre. (chamada pelo dono do jogo)
+func personagemColocar(jogo *Jogo, item string) {
+	estoque, falta := &jogo.Inventario.Armadilhas, "colocar.sem_armadilhas"
+	if item == "isca" {
+		estoque, falta = &jogo.Inventario.Iscas, "colocar.sem_iscas"
+	}
+	if *estoque <= 0 {
+		personagemAvisar(jogo, falta)
+		return
+	}
+
+	alvo, ok := Ponto{jogo.PosX + jogo.direcao.X, jogo.PosY + jogo.direcao.Y}, false
+	if jogo.direcao != (Ponto{}) {
+		ok = armadilhaPodeColocar(jogo, alvo)
+	}
+	for _, v := range personagemVizinhos(jogo) {
+		if ok {
+			break
+		}
+		alvo, ok = v.Pos, armadilhaPodeColocar(jogo, v.Pos)
+	}
+	if !ok {
+		personagemAvisar(jogo, "colocar.sem_espaco")
+		return
+	}
+
+	*estoque--
+	if item == "isca" {
+		jogoPublicar(jogo, PedidoIsca{Pos: alvo})
+	} else {
+		jogoPublicar(jogo, PedidoArmadilha{Pos: alvo, Ativa: true, Armadilha: ArmadilhaLaco, DoJogador: true})
+	}
+}
+
+// Registra uma mensagem comum do jogador (chamada pelo dono do jogo)
+func personagemAvisar(jogo *Jogo, id string, valores ...any) {
+	jogoMensagem(jogo, GravidadeInfo, "jogador", id, valores...)
+}
+
+// Vizinho é o conteúdo de uma das quatro células ao lado do personagem
+type Vizinho struct {
+	Direcao  string // norte, sul, oeste ou leste
+	Pos      Ponto
+	Elemento Elemento
+}
+
+// Direções ao redor do personagem, na ordem em que são verificadas
+var direcoesVizinhas = []struct {
+	nome   string
+	dx, dy int
+}{{"norte", 0, -1}, {"sul", 0, 1}, {"oeste", -1, 0}, {"leste", 1, 0}}
+
+// Células vizinhas ao personagem que estão dentro do mapa (chamada pelo dono
+// do jogo)
+func personagemVizinhos(jogo *Jogo) []Vizinho {
+	var vizinhos []Vizinho
+	for _, d := range direcoesVizinhas {
+		p := Ponto{jogo.PosX + d.dx, jogo.PosY + d.dy}
+		if dentroDoMapa(jogo.Mapa, p) {
+			vizinhos = append(vizinhos, Vizinho{Direcao: d.nome, Pos: p, Elemento: jogo.Mapa[p.Y][p.X]})
+		}
+	}
+	return vizinhos
+}
+
+// Processa o evento do teclado e executa a ação correspondente (chamada pelo
+// dono do jogo)
+func personagemExecutarAcao(ev EventoTeclado, jogo *Jogo) bool {
+	// Depois do fim de jogo só é possível sair
+	if jogoTerminou(jogo) && ev.Tipo != "sair" {
+		return true
+	}
+
 	switch ev.Tipo {
 	case "sair":
-		jogo.StatusMsg = "Saindo do jogo..."
+		personagemAvisar(jogo, "acao.sair")
 		return false
 	case "interagir":
-		personagemInteragir(jogo, portalChan, tesouroChan)
+		personagemInteragir(jogo)
 	case "mover":
 		personagemMover(ev.Tecla, jogo)
+	case "clicar":
+		personagemCaminharAte(jogo, ev.X, ev.Y)
+	case "inspecionar":
+		personagemInspecionar(jogo, ev.X, ev.Y)
+	case "esperar":
+		personagemAvisar(jogo, "acao.esperar")
+	case "armar", "isca":
+		personagemColocar(jogo, ev.Tipo)
+	case "minimapa":
+		jogoAlternarMinimapa(jogo)
+	case "depurar":
+		jogoAlternarDepuracao(jogo)
+	case "inventario":
+		jogoMensagem(jogo, GravidadeInfo, "jogador", "acao.inventario",
+			jogo.Stats.TesourosColetados, jogo.Pontos, jogo.Vida, jogo.Inventario.Armadilhas, jogo.Inventario.Iscas)
+	case "salvar":
+		if err := jogoSalvar(jogo, arquivoSalvamento); err != nil {
+			jogoMensagem(jogo, GravidadeAviso, "jogador", "acao.salvar_falhou", err)
+		} else {
+			personagemAvisar(jogo, "acao.salvo", arquivoSalvamento)
+		}
 	}
 	return true // Continua o jogo
 }
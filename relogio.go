@@ -0,0 +1,306 @@
+// relogio.go - Fonte de tempo dos elementos: tempo real ou passo a passo
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// Relogio entrega aos elementos os pulsos que os fazem avançar. O tempo da
+// partida é virtual e só anda quando Avancar é chamado: os pulsos vencidos
+// são disparados um de cada vez, sempre na mesma ordem, e o relógio espera
+// cada elemento concluir o seu trabalho antes de disparar o próximo. No modo
+// passo a passo quem chama Avancar é o ambiente ou a simulação; no modo de
+// tempo real é o agendador, que avança um passo fixo a cada passo do relógio
+// do sistema.
+type Relogio struct {
+	passoAPasso bool
+	quantum     time.Duration // intervalo entre pulsos de mensagens (passo a passo)
+	passo       time.Duration // passo fixo do agendador (tempo real)
+	inicio      time.Time
+	origem      time.Duration // tempo da partida quando o relógio começou (zero, ou o de uma partida salva)
+	agora       time.Duration // tempo virtual decorrido
+	pulsos      []*Pulso      // pulsos pendentes, em ordem de criação
+	trava       chan bool     // exclusão mútua da lista de pulsos
+
+	// Relógio do sistema seguido no tempo real; os testes o trocam por um
+	// relógio que só anda quando mandam
+	agoraSistema func() time.Time
+	batidas      func(passo time.Duration) (<-chan time.Time, func()) // canal que bate a cada passo e a função que o para
+
+	// Pausa e atraso do modo de tempo real
+	pausado      bool          // o relógio está pausado
+	pausadoDesde time.Time     // início da pausa atual
+	tempoPausado time.Duration // soma das pausas já encerradas e do atraso descartado
+	passos       int           // passos dados pelo agendador
+	descartados  int           // passos que o agendador não conseguiu dar a tempo
+}
+
+// Pulso é um sinal periódico ou único entregue a um elemento pelo canal C
+type Pulso struct {
+	C         <-chan time.Time
+	c         chan time.Time
+	periodo   time.Duration
+	proximo   time.Duration
+	unico     bool
+	concluido chan bool
+	parado    chan bool
+	relogio   *Relogio
+}
+
+// Passos que o agendador dá de uma vez para alcançar o tempo real; o atraso
+// além disso é descartado, para a partida não disparar depois de um engasgo
+const passosAtrasadosMax = 10
+
+// Cria um relógio que segue o tempo real, avançando um passo fixo por vez
+// depois que Agendar é chamado
+func relogioTempoReal(passo time.Duration) *Relogio {
+	r := &Relogio{
+		passo:        passo,
+		inicio:       time.Now(),
+		trava:        make(chan bool, 1),
+		agoraSistema: time.Now,
+		batidas:      batidasSistema,
+	}
+	r.trava <- true
+	return r
+}
+
+// Batidas do relógio do sistema, uma a cada passo
+func batidasSistema(passo time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(passo)
+	return ticker.C, ticker.Stop
+}
+
+// Cria um relógio virtual que só avança quando Avancar é chamado
+func relogioPassoAPasso(quantum time.Duration) *Relogio {
+	r := relogioTempoReal(quantum)
+	r.passoAPasso = true
+	r.quantum = quantum
+	return r
+}
+
+// Tempo da partida, sem contar as pausas. Em tempo real ele anda em passos
+// fixos, então todos os elementos veem o mesmo instante durante um passo.
+func (r *Relogio) Agora() time.Duration {
+	<-r.trava
+	defer func() { r.trava <- true }()
+	return r.agora
+}
+
+// Faz o tempo da partida começar do instante dado em vez de zero, para
+// continuar uma partida salva. Deve ser chamada antes de criar os pulsos.
+func (r *Relogio) comecarEm(agora time.Duration) {
+	r.origem, r.agora = agora, agora
+}
+
+// Tempo da partida que o agendador deve alcançar no instante dado: a origem
+// mais o tempo real decorrido desde o início do agendador, sem contar as
+// pausas (chamada com a trava obtida)
+func (r *Relogio) decorrido(instante time.Time) time.Duration {
+	if r.pausado {
+		instante = r.pausadoDesde
+	}
+	return r.origem + instante.Sub(r.inicio) - r.tempoPausado
+}
+
+// Agendador do modo de tempo real: a cada batida do relógio do sistema o
+// tempo da partida avança os passos devidos até o instante da batida,
+// disparando os pulsos vencidos em ordem. Um atraso maior que
+// passosAtrasadosMax é descartado e contado. Roda até o contexto ser
+// cancelado.
+func (r *Relogio) Agendar(ctx context.Context) {
+	<-r.trava
+	r.inicio = r.agoraSistema()
+	r.trava <- true
+
+	batidas, parar := r.batidas(r.passo)
+	defer parar()
+	for {
+		var instante time.Time
+		select {
+		case <-ctx.Done():
+			return
+		case instante = <-batidas:
+		}
+
+		<-r.trava
+		devidos := int((r.decorrido(instante) - r.agora) / r.passo)
+		if devidos > passosAtrasadosMax {
+			r.descartados += devidos - passosAtrasadosMax
+			r.tempoPausado += time.Duration(devidos-passosAtrasadosMax) * r.passo
+			devidos = passosAtrasadosMax
+		}
+		r.passos += max(devidos, 0)
+		r.trava <- true
+
+		for range devidos {
+			r.avancar(r.passo, ctx.Done())
+		}
+	}
+}
+
+// Passo do agendador, passos já dados e passos descartados por atraso
+func (r *Relogio) Passos() (passo time.Duration, dados, descartados int) {
+	<-r.trava
+	defer func() { r.trava <- true }()
+	return r.passo, r.passos, r.descartados
+}
+
+// Congela o tempo real: o agendador não avança até Retomar. No modo passo a
+// passo não faz nada, pois o tempo só anda quando Avancar é chamado.
+func (r *Relogio) Pausar() {
+	<-r.trava
+	defer func() { r.trava <- true }()
+	if r.passoAPasso || r.pausado {
+		return
+	}
+	r.pausado = true
+	r.pausadoDesde = r.agoraSistema()
+}
+
+// Volta a entregar os pulsos depois de Pausar
+func (r *Relogio) Retomar() {
+	<-r.trava
+	defer func() { r.trava <- true }()
+	if !r.pausado {
+		return
+	}
+	r.pausado = false
+	r.tempoPausado += r.agoraSistema().Sub(r.pausadoDesde)
+}
+
+// Indica se o relógio está pausado
+func (r *Relogio) Pausado() bool {
+	<-r.trava
+	defer func() { r.trava <- true }()
+	return r.pausado
+}
+
+// Cria um pulso que dispara a cada período
+func (r *Relogio) pulsar(periodo time.Duration) *Pulso {
+	return r.registrar(periodo, false)
+}
+
+// Cria um pulso que dispara uma única vez após a duração indicada
+func (r *Relogio) apos(d time.Duration) *Pulso {
+	return r.registrar(d, true)
+}
+
+// Cria o pulso em que um elemento deve ler as suas mensagens. Em tempo real
+// as mensagens são lidas assim que chegam e este pulso nunca dispara.
+func (r *Relogio) pulsoMensagens() *Pulso {
+	if !r.passoAPasso {
+		return &Pulso{relogio: r}
+	}
+	return r.registrar(r.quantum, false)
+}
+
+func (r *Relogio) registrar(d time.Duration, unico bool) *Pulso {
+	c := make(chan time.Time)
+	p := &Pulso{
+		C:         c,
+		c:         c,
+		periodo:   d,
+		unico:     unico,
+		concluido: make(chan bool),
+		parado:    make(chan bool),
+		relogio:   r,
+	}
+	<-r.trava
+	p.proximo = r.agora + d
+	r.pulsos = append(r.pulsos, p)
+	r.trava <- true
+	return p
+}
+
+// Avança o tempo virtual, disparando em ordem todos os pulsos que vencerem
+func (r *Relogio) Avancar(d time.Duration) {
+	r.avancar(d, nil)
+}
+
+// Avança o tempo virtual; com done fechado para de esperar pelos elementos,
+// que podem já ter terminado sem parar os seus pulsos
+func (r *Relogio) avancar(d time.Duration, done <-chan struct{}) {
+	<-r.trava
+	fim := r.agora + d
+	r.trava <- true
+
+	for {
+		<-r.trava
+		// O pulso mais antigo vence; nos empates, o que foi criado primeiro
+		var prox *Pulso
+		for _, p := range r.pulsos {
+			if p.proximo <= fim && (prox == nil || p.proximo < prox.proximo) {
+				prox = p
+			}
+		}
+		if prox == nil {
+			r.agora = fim
+			r.trava <- true
+			return
+		}
+		r.agora = prox.proximo
+		if prox.unico {
+			r.remover(prox)
+		} else {
+			prox.proximo += prox.periodo
+		}
+		instante := r.inicio.Add(r.agora)
+		r.trava <- true
+
+		select {
+		case prox.c <- instante:
+			select {
+			case <-prox.concluido:
+			case <-done:
+				return
+			}
+		case <-prox.parado:
+		case <-done:
+			return
+		}
+	}
+}
+
+// Remove um pulso da lista (chamada com a trava obtida)
+func (r *Relogio) remover(alvo *Pulso) {
+	for i, p := range r.pulsos {
+		if p == alvo {
+			r.pulsos = append(r.pulsos[:i], r.pulsos[i+1:]...)
+			return
+		}
+	}
+}
+
+// Avisa o relógio de que o elemento terminou de tratar o pulso recebido
+func (p *Pulso) Concluir() {
+	if p.concluido != nil {
+		p.concluido <- true
+	}
+}
+
+// Cancela o pulso; ele não dispara mais
+func (p *Pulso) Parar() {
+	if p.parado == nil {
+		return // pulso de mensagens em tempo real, que nunca dispara
+	}
+	<-p.relogio.trava
+	p.relogio.remover(p)
+	select {
+	case <-p.parado:
+	default:
+		close(p.parado)
+	}
+	p.relogio.trava <- true
+}
+
+// Canal de onde o elemento lê mensagens diretamente. No modo passo a passo
+// retorna nil, e as mensagens só são lidas com Drenar durante um pulso, para
+// que a ordem de processamento seja sempre a mesma.
+func entrada[T any](r *Relogio, ch <-chan T) <-chan T {
+	if r.passoAPasso {
+		return nil
+	}
+	return ch
+}
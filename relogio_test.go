@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Relógio do sistema controlado pelo teste: o tempo só anda quando o teste
+// manda, e cada batida é entregue ao agendador à mão
+type sistemaTeste struct {
+	agora   time.Time
+	batidas chan time.Time
+}
+
+// Cria um relógio de tempo real que segue o sistema controlado
+func relogioTeste(passo time.Duration) (*Relogio, *sistemaTeste) {
+	s := &sistemaTeste{agora: time.Unix(0, 0), batidas: make(chan time.Time)}
+	r := relogioTempoReal(passo)
+	r.agoraSistema = func() time.Time { return s.agora }
+	r.batidas = func(time.Duration) (<-chan time.Time, func()) { return s.batidas, func() {} }
+	return r, s
+}
+
+// Faz o tempo do sistema andar d e bate uma vez. Cada batida só é recebida
+// com o agendador parado à espera dela, então a do instante anterior garante
+// que ele já começou e a do mesmo instante, que ele tratou a primeira.
+func (s *sistemaTeste) andar(d time.Duration) {
+	s.batidas <- s.agora
+	s.agora = s.agora.Add(d)
+	s.batidas <- s.agora
+	s.batidas <- s.agora
+}
+
+// Elemento que conta os pulsos recebidos até done ser fechado
+func contarPulsos(p *Pulso, done <-chan struct{}) *int {
+	recebidos := new(int)
+	go func() {
+		for {
+			select {
+			case <-p.C:
+				*recebidos++
+				p.Concluir()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return recebidos
+}
+
+func TestAgendarAlcancaTempoReal(t *testing.T) {
+	const passo = 10 * time.Millisecond
+	casos := []struct {
+		nome               string
+		batidas            []time.Duration // quanto o sistema anda antes de cada batida
+		dados, descartados int
+	}{
+		{"em dia", []time.Duration{passo, passo, passo}, 3, 0},
+		{"meio passo espera o próximo", []time.Duration{passo / 2, passo / 2, passo / 2}, 1, 0},
+		{"atraso dentro do limite", []time.Duration{7 * passo}, 7, 0},
+		{"engasgo além do limite", []time.Duration{40 * passo}, passosAtrasadosMax, 40 - passosAtrasadosMax},
+		{"volta a ficar em dia depois do engasgo", []time.Duration{40 * passo, passo, passo}, passosAtrasadosMax + 2, 40 - passosAtrasadosMax},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			r, sistema := relogioTeste(passo)
+			ctx, cancelar := context.WithCancel(context.Background())
+			defer cancelar()
+			recebidos := contarPulsos(r.pulsar(passo), ctx.Done())
+			go r.Agendar(ctx)
+
+			for _, d := range c.batidas {
+				sistema.andar(d)
+			}
+			_, dados, descartados := r.Passos()
+			if dados != c.dados || descartados != c.descartados {
+				t.Fatalf("%d passos dados e %d descartados, esperava %d e %d", dados, descartados, c.dados, c.descartados)
+			}
+			if agora := r.Agora(); agora != time.Duration(c.dados)*passo {
+				t.Fatalf("tempo da partida %v, esperava %v", agora, time.Duration(c.dados)*passo)
+			}
+			if *recebidos != c.dados {
+				t.Fatalf("%d pulsos entregues, esperava %d", *recebidos, c.dados)
+			}
+		})
+	}
+}
+
+func TestAgendarPausado(t *testing.T) {
+	const passo = 10 * time.Millisecond
+	r, sistema := relogioTeste(passo)
+	ctx, cancelar := context.WithCancel(context.Background())
+	defer cancelar()
+	go r.Agendar(ctx)
+
+	sistema.andar(3 * passo)
+	r.Pausar()
+	sistema.andar(50 * passo)
+	if agora := r.Agora(); agora != 3*passo {
+		t.Fatalf("o relógio andou para %v pausado", agora)
+	}
+
+	// A pausa não conta como atraso: nada é descartado ao retomar
+	r.Retomar()
+	sistema.andar(2 * passo)
+	if _, dados, descartados := r.Passos(); dados != 5 || descartados != 0 {
+		t.Fatalf("%d passos dados e %d descartados depois da pausa, esperava 5 e 0", dados, descartados)
+	}
+}
+
+func TestAgendarPartidaSalva(t *testing.T) {
+	const passo = 10 * time.Millisecond
+	r, sistema := relogioTeste(passo)
+	r.comecarEm(100 * passo)
+	ctx, cancelar := context.WithCancel(context.Background())
+	defer cancelar()
+	go r.Agendar(ctx)
+
+	// O tempo já passado na partida salva não conta como atraso
+	sistema.andar(2 * passo)
+	if _, dados, descartados := r.Passos(); dados != 2 || descartados != 0 {
+		t.Fatalf("%d passos dados e %d descartados, esperava 2 e 0", dados, descartados)
+	}
+	if agora := r.Agora(); agora != 102*passo {
+		t.Fatalf("tempo da partida %v, esperava %v", agora, 102*passo)
+	}
+}
@@ -0,0 +1,238 @@
+// salvamento.go - Salvamento de uma partida em andamento e carregamento para continuá-la
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+)
+
+// Versão do formato do arquivo; salvamentos de outra versão são recusados
+const versaoSalvamento = 1
+
+// Arquivo onde a ação salvar grava a partida
+const arquivoSalvamento = "partida_salva.json"
+
+// Salvamento é o estado completo de uma partida, gravado em JSON. Os instantes
+// são do relógio da partida, que ao carregar continua de Agora. Os geradores
+// aleatórios não são guardados: a partida carregada volta a sortear a partir
+// da semente.
+type Salvamento struct {
+	Versao  int           `json:"versao"`
+	Mapa    string        `json:"mapa"` // arquivo do mapa de onde a partida começou
+	Semente int64         `json:"semente"`
+	Agora   time.Duration `json:"agora"`
+
+	Terreno []string `json:"terreno"` // linhas do mapa com os símbolos dos elementos, sem o personagem
+	Rotas   []string `json:"rotas"`   // rotas de patrulha, no formato do cabeçalho do mapa
+
+	Jogador        Ponto                    `json:"jogador"`
+	Direcao        Ponto                    `json:"direcao"`
+	UltimoVisitado Elemento                 `json:"ultimo_visitado"`
+	Caminho        []Ponto                  `json:"caminho,omitempty"`
+	PresoAte       time.Duration            `json:"preso_ate"`
+	Vida           int                      `json:"vida"`
+	Pontos         int                      `json:"pontos"`
+	Stats          Estatisticas             `json:"stats"`
+	Inventario     Inventario               `json:"inventario"`
+	EstadoGuardiao EstadoGuardiao           `json:"estado_guardiao"`
+	AlertaGuardiao bool                     `json:"alerta_guardiao"`
+	Armadilhas     []ArmadilhaInstalada     `json:"armadilhas,omitempty"`
+	Iscas          []iscaSalva              `json:"iscas,omitempty"`
+	Atordoados     map[string]time.Duration `json:"atordoados,omitempty"`
+	Mensagens      []Mensagem               `json:"mensagens"`
+
+	// Estado que cada elemento guarda nas suas goroutines, pelo nome dele
+	Elementos map[string]json.RawMessage `json:"elementos"`
+}
+
+// Isca no mapa e o instante em que some
+type iscaSalva struct {
+	Pos  Ponto         `json:"pos"`
+	Some time.Duration `json:"some"`
+}
+
+// Todos os elementos que podem ocupar uma célula, pelo símbolo
+var elementosPorSimbolo = map[rune]Elemento{}
+
+func init() {
+	for _, e := range []Elemento{
+		Personagem, Inimigo, Parede, Vegetacao, Vazio, Portal, Armadilha, Fantasma, Tesouro, Guardian,
+		Perseguidor, Laco, Alarme, Teleporte, ArmadilhaGasta, ArmadilhaJogador, Isca,
+	} {
+		elementosPorSimbolo[e.simbolo] = e
+	}
+}
+
+// Grava o elemento pelo símbolo
+func (e Elemento) MarshalText() ([]byte, error) {
+	return []byte(string(e.simbolo)), nil
+}
+
+// Lê o elemento pelo símbolo
+func (e *Elemento) UnmarshalText(texto []byte) error {
+	runas := []rune(string(texto))
+	if len(runas) != 1 {
+		return erroTraduzido("erro.salvamento_elemento", string(texto))
+	}
+	elem, ok := elementosPorSimbolo[runas[0]]
+	if !ok {
+		return erroTraduzido("erro.salvamento_elemento", string(texto))
+	}
+	*e = elem
+	return nil
+}
+
+// Registra como o elemento guarda o seu estado no salvamento. estado é
+// chamada pelo dono do jogo, então só pode ler o que o elemento altera
+// dentro das suas intenções.
+func jogoSalvarElemento(jogo *Jogo, nome string, estado func() any) {
+	jogoExecutar(jogo, func() {
+		jogo.salvar[nome] = estado
+	})
+}
+
+// Lê em destino o estado que o elemento deixou no salvamento carregado.
+// Retorna false numa partida nova ou se o salvamento não tem o elemento;
+// nesse caso o elemento começa como numa partida nova.
+func jogoRestaurarElemento(jogo *Jogo, nome string, destino any) bool {
+	dados, ok := jogo.carregado[nome]
+	return ok && json.Unmarshal(dados, destino) == nil
+}
+
+// Grava a partida em andamento no arquivo (chamada pelo dono do jogo)
+func jogoSalvar(jogo *Jogo, nome string) error {
+	s := Salvamento{
+		Versao:         versaoSalvamento,
+		Mapa:           jogo.mapa,
+		Semente:        jogo.semente,
+		Agora:          jogo.relogio.Agora(),
+		Jogador:        Ponto{jogo.PosX, jogo.PosY},
+		Direcao:        jogo.direcao,
+		UltimoVisitado: jogo.UltimoVisitado,
+		Caminho:        jogo.caminhoJogador,
+		PresoAte:       jogo.presoAte,
+		Vida:           jogo.Vida,
+		Pontos:         jogo.Pontos,
+		Stats:          jogo.Stats,
+		Inventario:     jogo.Inventario,
+		EstadoGuardiao: jogo.EstadoGuardiao,
+		AlertaGuardiao: jogo.AlertaGuardiao,
+		Atordoados:     jogo.atordoados,
+		Mensagens:      jogo.Mensagens.Todas(),
+		Elementos:      map[string]json.RawMessage{},
+	}
+	for _, linha := range jogo.Mapa {
+		runas := make([]rune, len(linha))
+		for x, e := range linha {
+			runas[x] = e.simbolo
+		}
+		s.Terreno = append(s.Terreno, string(runas))
+	}
+	for _, rota := range jogo.Rotas {
+		s.Rotas = append(s.Rotas, rotaFormatar(rota))
+	}
+	for _, a := range jogo.armadilhas {
+		s.Armadilhas = append(s.Armadilhas, *a)
+	}
+	sort.Slice(s.Armadilhas, func(i, j int) bool { return pontoAntes(s.Armadilhas[i].Pos, s.Armadilhas[j].Pos) })
+	for p, some := range jogo.iscas {
+		s.Iscas = append(s.Iscas, iscaSalva{Pos: p, Some: some})
+	}
+	sort.Slice(s.Iscas, func(i, j int) bool { return pontoAntes(s.Iscas[i].Pos, s.Iscas[j].Pos) })
+	for elemento, estado := range jogo.salvar {
+		dados, err := json.Marshal(estado())
+		if err != nil {
+			return err
+		}
+		s.Elementos[elemento] = dados
+	}
+
+	dados, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(nome, append(dados, '\n'), 0o644)
+}
+
+// Lê um arquivo gravado pela ação salvar
+func salvamentoLer(nome string) (*Salvamento, error) {
+	dados, err := os.ReadFile(nome)
+	if err != nil {
+		return nil, err
+	}
+	var s Salvamento
+	if err := json.Unmarshal(dados, &s); err != nil {
+		return nil, erroTraduzido("erro.salvamento", nome, err)
+	}
+	if s.Versao != versaoSalvamento {
+		return nil, erroTraduzido("erro.salvamento_versao", nome, s.Versao, versaoSalvamento)
+	}
+	return &s, nil
+}
+
+// Monta o jogo a partir do salvamento, no lugar de carregar um mapa. O estado
+// dos elementos fica guardado para cada um retomar o seu ao ser iniciado.
+func jogoRestaurar(jogo *Jogo, s *Salvamento) error {
+	for _, texto := range s.Terreno {
+		var linha []Elemento
+		for _, ch := range texto {
+			e, ok := elementosPorSimbolo[ch]
+			if !ok || e == Personagem {
+				return erroTraduzido("erro.salvamento_elemento", string(ch))
+			}
+			linha = append(linha, e)
+		}
+		jogo.Mapa = append(jogo.Mapa, linha)
+	}
+	if !dentroDoMapa(jogo.Mapa, s.Jogador) {
+		return erroTraduzido("erro.salvamento_jogador", s.Jogador.X, s.Jogador.Y)
+	}
+	for _, linha := range s.Rotas {
+		rota, err := rotaInterpretar(linha)
+		if err != nil {
+			return err
+		}
+		jogo.Rotas = append(jogo.Rotas, rota)
+	}
+	if err := rotasValidar(jogo.Rotas, jogo.Mapa); err != nil {
+		return err
+	}
+
+	jogo.PosX, jogo.PosY = s.Jogador.X, s.Jogador.Y
+	jogo.direcao = s.Direcao
+	jogo.UltimoVisitado = s.UltimoVisitado
+	jogo.caminhoJogador = s.Caminho
+	jogo.presoAte = s.PresoAte
+	jogo.Vida, jogo.Pontos = s.Vida, s.Pontos
+	jogo.Stats = s.Stats
+	jogo.Inventario = s.Inventario
+	jogo.EstadoGuardiao, jogo.AlertaGuardiao = s.EstadoGuardiao, s.AlertaGuardiao
+	for _, a := range s.Armadilhas {
+		if !dentroDoMapa(jogo.Mapa, a.Pos) {
+			return erroTraduzido("erro.salvamento_posicao", a.Pos.X, a.Pos.Y)
+		}
+		jogo.armadilhas[a.Pos] = &a
+	}
+	for _, isca := range s.Iscas {
+		if !dentroDoMapa(jogo.Mapa, isca.Pos) {
+			return erroTraduzido("erro.salvamento_posicao", isca.Pos.X, isca.Pos.Y)
+		}
+		jogo.iscas[isca.Pos] = isca.Some
+	}
+	for quem, ate := range s.Atordoados {
+		jogo.atordoados[quem] = ate
+	}
+	jogo.Mensagens.Restaurar(s.Mensagens)
+	jogo.carregado = s.Elementos
+	return nil
+}
+
+// Ordem de leitura do mapa: linha a linha, da esquerda para a direita
+func pontoAntes(a, b Ponto) bool {
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.X < b.X
+}
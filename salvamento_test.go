@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Inicia uma partida passo a passo sem tela, nova ou a partir do salvamento
+func partidaTeste(t *testing.T, mapa string, salvamento *Salvamento) *Partida {
+	t.Helper()
+	p, err := partidaNova(context.Background(), mapa, OpcoesPartida{
+		Semente:     7,
+		PassoAPasso: true,
+		SemTela:     true,
+		Salvamento:  salvamento,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { partidaEncerrar(p) })
+	return p
+}
+
+// Salva a partida no arquivo e retorna o que foi gravado
+func salvarTeste(t *testing.T, p *Partida, nome string) []byte {
+	t.Helper()
+	var err error
+	jogoExecutar(p.Jogo, func() { err = jogoSalvar(p.Jogo, nome) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	dados, err := os.ReadFile(nome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dados
+}
+
+func TestSalvamentoContinuaPartida(t *testing.T) {
+	for _, mapa := range []string{"mapa.txt", "patrulhas.txt", "enxame.txt"} {
+		t.Run(mapa, func(t *testing.T) {
+			dir := t.TempDir()
+			original := partidaTeste(t, mapa, nil)
+			for range 300 {
+				partidaAvancar(original, 100*time.Millisecond)
+			}
+			antes := salvarTeste(t, original, filepath.Join(dir, "antes.json"))
+
+			s, err := salvamentoLer(filepath.Join(dir, "antes.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Mapa != mapa || s.Agora != 30*time.Second {
+				t.Fatalf("salvamento do mapa %q em %v, esperava %q em 30s", s.Mapa, s.Agora, mapa)
+			}
+
+			// A partida carregada, salva de novo antes de andar, grava o mesmo estado
+			carregada := partidaTeste(t, "", s)
+			depois := salvarTeste(t, carregada, filepath.Join(dir, "depois.json"))
+			if !bytes.Equal(antes, depois) {
+				t.Fatalf("a partida carregada difere da salva:\n%s\n---\n%s", antes, depois)
+			}
+			if agora := carregada.Jogo.relogio.Agora(); agora != s.Agora {
+				t.Fatalf("a partida carregada começou em %v, esperava %v", agora, s.Agora)
+			}
+
+			// E continua andando a partir dali
+			for range 10 {
+				partidaAvancar(carregada, 100*time.Millisecond)
+			}
+			if agora := carregada.Jogo.relogio.Agora(); agora != s.Agora+time.Second {
+				t.Fatalf("a partida carregada está em %v, esperava %v", agora, s.Agora+time.Second)
+			}
+		})
+	}
+}
+
+func TestSalvamentoVersaoDesconhecida(t *testing.T) {
+	nome := filepath.Join(t.TempDir(), "partida.json")
+	if err := os.WriteFile(nome, []byte(`{"versao": 99}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := salvamentoLer(nome); err == nil {
+		t.Fatal("um salvamento de outra versão deveria ser recusado")
+	}
+}
@@ -0,0 +1,236 @@
+// simulacao.go - Simulação em lote de partidas sem interface, para balanceamento
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Resultado de uma partida simulada
+type ResultadoSimulacao struct {
+	Semente       int64
+	Pontos        int
+	Sobrevivencia time.Duration // tempo de jogo até perder todas as vidas ou atingir o limite
+	Morreu        bool
+	Stats         Estatisticas
+}
+
+// Opções do comando simular
+type opcoesSimulacao struct {
+	mapa     string
+	bot      string
+	partidas int
+	semente  int64
+	passos   int
+	paralelo int
+	dt       time.Duration
+	formato  string
+	config   *Configuracao
+}
+
+// Executa o comando "simular" com os argumentos da linha de comando
+func simularComando(args []string, saida io.Writer) error {
+	var op opcoesSimulacao
+	idiomaAntecipar(args)
+	fs := flag.NewFlagSet("simular", flag.ContinueOnError)
+	fs.StringVar(&op.bot, "bot", "guloso", traduzir("ajuda.simular_bot"))
+	fs.IntVar(&op.partidas, "n", 100, traduzir("ajuda.simular_n"))
+	fs.Int64Var(&op.semente, "semente", 1, traduzir("ajuda.simular_semente"))
+	fs.IntVar(&op.passos, "passos", 3000, traduzir("ajuda.simular_passos"))
+	fs.IntVar(&op.paralelo, "paralelo", 4, traduzir("ajuda.simular_paralelo"))
+	fs.DurationVar(&op.dt, "dt", 100*time.Millisecond, traduzir("ajuda.simular_dt"))
+	fs.StringVar(&op.formato, "formato", "tabela", traduzir("ajuda.simular_formato"))
+	arquivoConfig := fs.String("config", "", traduzir("ajuda.config"))
+	idioma := fs.String("idioma", "", traduzir("ajuda.idioma"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := idiomaEscolher(*idioma); err != nil {
+		return err
+	}
+	op.mapa = "mapa.txt"
+	if fs.NArg() > 0 {
+		op.mapa = fs.Arg(0)
+	}
+	config, err := configCarregar(*arquivoConfig, op.mapa)
+	if err != nil {
+		return err
+	}
+	op.config = config
+
+	if _, ok := agentesDisponiveis[op.bot]; !ok {
+		return erroTraduzido("erro.agente", op.bot)
+	}
+	if op.partidas < 1 || op.paralelo < 1 || op.passos < 1 {
+		return erroTraduzido("erro.simulacao_positivos")
+	}
+	if op.formato != "tabela" && op.formato != "csv" {
+		return erroTraduzido("erro.formato", op.formato)
+	}
+
+	resultados, err := simularLote(op)
+	if err != nil {
+		return err
+	}
+	if op.formato == "csv" {
+		return simulacaoEscreverCSV(resultados, saida)
+	}
+	return simulacaoEscreverTabela(op, resultados, saida)
+}
+
+// Roda todas as partidas, distribuindo as sementes entre os trabalhadores
+func simularLote(op opcoesSimulacao) ([]ResultadoSimulacao, error) {
+	sementes := make(chan int64)
+	resultados := make(chan ResultadoSimulacao)
+	erros := make(chan error, op.paralelo)
+
+	for i := 0; i < op.paralelo; i++ {
+		go func() {
+			for semente := range sementes {
+				r, err := simularPartida(op, semente)
+				if err != nil {
+					erros <- err
+					return
+				}
+				resultados <- r
+			}
+		}()
+	}
+
+	go func() {
+		defer close(sementes)
+		for i := 0; i < op.partidas; i++ {
+			sementes <- op.semente + int64(i)
+		}
+	}()
+
+	lista := make([]ResultadoSimulacao, 0, op.partidas)
+	for len(lista) < op.partidas {
+		select {
+		case r := <-resultados:
+			lista = append(lista, r)
+		case err := <-erros:
+			return nil, err
+		}
+	}
+	sort.Slice(lista, func(i, j int) bool { return lista[i].Semente < lista[j].Semente })
+	return lista, nil
+}
+
+// Joga uma partida completa, no modo passo a passo, com o agente escolhido
+func simularPartida(op opcoesSimulacao, semente int64) (ResultadoSimulacao, error) {
+	partida, err := partidaNova(context.Background(), op.mapa, OpcoesPartida{
+		Semente:     semente,
+		PassoAPasso: true,
+		Quantum:     op.dt,
+		SemTela:     true,
+		Config:      op.config,
+	})
+	if err != nil {
+		return ResultadoSimulacao{}, err
+	}
+	defer partidaEncerrar(partida)
+
+	agente := agentesDisponiveis[op.bot](semente)
+	obs := jogoObservar(partida.Jogo)
+	for passo := 0; passo < op.passos && !obs.Terminou; passo++ {
+		partidaExecutar(partida, agente.Decidir(obs))
+		partidaAvancar(partida, op.dt)
+		obs = jogoObservar(partida.Jogo)
+	}
+
+	return ResultadoSimulacao{
+		Semente:       semente,
+		Pontos:        obs.Pontos,
+		Sobrevivencia: partida.Jogo.relogio.Agora(),
+		Morreu:        obs.Terminou,
+		Stats:         obs.Stats,
+	}, nil
+}
+
+// Métricas resumidas na tabela, na ordem em que aparecem
+var metricasSimulacao = []struct {
+	nome  string
+	valor func(r ResultadoSimulacao) float64
+}{
+	{"pontos", func(r ResultadoSimulacao) float64 { return float64(r.Pontos) }},
+	{"sobrevivencia_s", func(r ResultadoSimulacao) float64 { return r.Sobrevivencia.Seconds() }},
+	{"tesouros", func(r ResultadoSimulacao) float64 { return float64(r.Stats.TesourosColetados) }},
+	{"armadilhas", func(r ResultadoSimulacao) float64 { return float64(r.Stats.ArmadilhasAtingidas) }},
+	{"portais", func(r ResultadoSimulacao) float64 { return float64(r.Stats.PortaisUsados) }},
+	{"capturas_fantasma", func(r ResultadoSimulacao) float64 { return float64(r.Stats.CapturasFantasma) }},
+	{"capturas_enxame", func(r ResultadoSimulacao) float64 { return float64(r.Stats.CapturasEnxame) }},
+	{"golpes_guardiao", func(r ResultadoSimulacao) float64 { return float64(r.Stats.AtaquesGuardiao) }},
+}
+
+// Escreve a distribuição de cada métrica: mínimo, média, mediana, p90 e máximo
+func simulacaoEscreverTabela(op opcoesSimulacao, resultados []ResultadoSimulacao, saida io.Writer) error {
+	mortes := 0
+	for _, r := range resultados {
+		if r.Morreu {
+			mortes++
+		}
+	}
+	fmt.Fprintln(saida, traduzir("simulacao.resumo",
+		op.mapa, op.bot, len(resultados), op.semente, op.semente+int64(op.partidas)-1, op.passos))
+	fmt.Fprintln(saida, traduzir("simulacao.mortes", mortes, 100*float64(mortes)/float64(len(resultados))))
+	fmt.Fprintln(saida)
+
+	tw := tabwriter.NewWriter(saida, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, traduzir("simulacao.colunas"))
+	for _, m := range metricasSimulacao {
+		valores := make([]float64, len(resultados))
+		for i, r := range resultados {
+			valores[i] = m.valor(r)
+		}
+		sort.Float64s(valores)
+		fmt.Fprintf(tw, "%s\t%.1f\t%.2f\t%.1f\t%.1f\t%.1f\t\n", m.nome,
+			valores[0], media(valores), percentil(valores, 0.5), percentil(valores, 0.9), valores[len(valores)-1])
+	}
+	return tw.Flush()
+}
+
+// Escreve uma linha CSV por partida
+func simulacaoEscreverCSV(resultados []ResultadoSimulacao, saida io.Writer) error {
+	w := csv.NewWriter(saida)
+	cabecalho := []string{"semente", "morreu"}
+	for _, m := range metricasSimulacao {
+		cabecalho = append(cabecalho, m.nome)
+	}
+	w.Write(cabecalho)
+	for _, r := range resultados {
+		linha := []string{strconv.FormatInt(r.Semente, 10), strconv.FormatBool(r.Morreu)}
+		for _, m := range metricasSimulacao {
+			linha = append(linha, strconv.FormatFloat(m.valor(r), 'f', -1, 64))
+		}
+		w.Write(linha)
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Média aritmética de uma lista não vazia
+func media(valores []float64) float64 {
+	soma := 0.0
+	for _, v := range valores {
+		soma += v
+	}
+	return soma / float64(len(valores))
+}
+
+// Percentil de uma lista ordenada, pelo método do valor mais próximo
+func percentil(ordenados []float64, p float64) float64 {
+	i := int(math.Ceil(p*float64(len(ordenados)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return ordenados[i]
+}
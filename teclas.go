@@ -0,0 +1,255 @@
+// teclas.go - Mapeamento configurável de teclas para ações do jogador
+package main
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/nsf/termbox-go"
+)
+
+// Tecla identifica uma tecla pressionada: um caractere ou uma tecla especial
+type Tecla struct {
+	Ch  rune
+	Key termbox.Key
+}
+
+// Nomes das teclas especiais aceitos na configuração
+var teclasEspeciais = map[string]termbox.Key{
+	"seta_cima":     termbox.KeyArrowUp,
+	"seta_baixo":    termbox.KeyArrowDown,
+	"seta_esquerda": termbox.KeyArrowLeft,
+	"seta_direita":  termbox.KeyArrowRight,
+	"esc":           termbox.KeyEsc,
+	"espaco":        termbox.KeySpace,
+	"enter":         termbox.KeyEnter,
+	"tab":           termbox.KeyTab,
+	"backspace":     termbox.KeyBackspace2,
+	"ctrl+s":        termbox.KeyCtrlS,
+	"f1":            termbox.KeyF1,
+	"f2":            termbox.KeyF2,
+	"f3":            termbox.KeyF3,
+	"f4":            termbox.KeyF4,
+	"f5":            termbox.KeyF5,
+	"f6":            termbox.KeyF6,
+	"f7":            termbox.KeyF7,
+	"f8":            termbox.KeyF8,
+	"f9":            termbox.KeyF9,
+	"f10":           termbox.KeyF10,
+	"f11":           termbox.KeyF11,
+	"f12":           termbox.KeyF12,
+}
+
+// Evento gerado por cada ação. Os movimentos usam sempre as teclas WASD,
+// que é o que personagemMover entende, qualquer que seja a tecla física.
+var acoesTeclado = map[string]EventoTeclado{
+	"cima":       {Tipo: "mover", Tecla: 'w'},
+	"esquerda":   {Tipo: "mover", Tecla: 'a'},
+	"baixo":      {Tipo: "mover", Tecla: 's'},
+	"direita":    {Tipo: "mover", Tecla: 'd'},
+	"interagir":  {Tipo: "interagir"},
+	"esperar":    {Tipo: "esperar"},
+	"armar":      {Tipo: "armar"},
+	"isca":       {Tipo: "isca"},
+	"pausar":     {Tipo: "pausar"},
+	"inventario": {Tipo: "inventario"},
+	"salvar":     {Tipo: "salvar"},
+	"mensagens":  {Tipo: "mensagens"},
+	"minimapa":   {Tipo: "minimapa"},
+	"depurar":    {Tipo: "depurar"},
+	"sair":       {Tipo: "sair"},
+}
+
+// Teclas comuns a todos os layouts
+var teclasComuns = map[string][]string{
+	"cima":       {"seta_cima"},
+	"esquerda":   {"seta_esquerda"},
+	"baixo":      {"seta_baixo"},
+	"direita":    {"seta_direita"},
+	"esperar":    {"espaco"},
+	"armar":      {"f"},
+	"isca":       {"r"},
+	"pausar":     {"p"},
+	"inventario": {"tab"},
+	"salvar":     {"ctrl+s"},
+	"mensagens":  {"m"},
+	"minimapa":   {"v"},
+	"depurar":    {"f3"},
+	"sair":       {"esc"},
+}
+
+// Layouts prontos. As letras ficam na mesma posição física do WASD em cada
+// teclado; maiúsculas funcionam como as minúsculas.
+var layoutsTeclado = map[string]map[string][]string{
+	"qwerty": {"cima": {"w"}, "esquerda": {"a"}, "baixo": {"s"}, "direita": {"d"}, "interagir": {"e"}},
+	"azerty": {"cima": {"z"}, "esquerda": {"q"}, "baixo": {"s"}, "direita": {"d"}, "interagir": {"e"}},
+	"dvorak": {"cima": {","}, "esquerda": {"a"}, "baixo": {"o"}, "direita": {"e"}, "interagir": {"."}},
+	"vi":     {"cima": {"k"}, "esquerda": {"h"}, "baixo": {"j"}, "direita": {"l"}, "interagir": {"i"}},
+}
+
+// Configuração das teclas: um layout pronto e, opcionalmente, a troca das
+// teclas de algumas ações
+type ConfigTeclas struct {
+	Layout  string              `json:"layout"`
+	Atalhos map[string][]string `json:"atalhos"` // ação -> teclas, substitui as do layout
+}
+
+// MapaTeclas traduz teclas em ações
+type MapaTeclas struct {
+	acoes  map[Tecla]string
+	teclas map[string][]string // nomes das teclas de cada ação, para a ajuda
+}
+
+// Monta o mapa de teclas a partir da configuração, recusando ações ou teclas
+// desconhecidas e teclas ligadas a mais de uma ação
+func teclasMontar(cfg ConfigTeclas) (*MapaTeclas, error) {
+	layout, ok := layoutsTeclado[cfg.Layout]
+	if !ok {
+		return nil, erroTraduzido("erro.layout", cfg.Layout)
+	}
+
+	// As teclas do layout vêm antes das comuns; os atalhos substituem as duas
+	ligacoes := make(map[string][]string)
+	for acao, nomes := range teclasComuns {
+		ligacoes[acao] = append(slices.Clone(layout[acao]), nomes...)
+	}
+	for acao, nomes := range layout {
+		if _, comum := teclasComuns[acao]; !comum {
+			ligacoes[acao] = nomes
+		}
+	}
+	for acao, nomes := range cfg.Atalhos {
+		if _, ok := acoesTeclado[acao]; !ok {
+			return nil, erroTraduzido("erro.atalho", acao)
+		}
+		ligacoes[acao] = nomes
+	}
+
+	m := &MapaTeclas{acoes: make(map[Tecla]string), teclas: ligacoes}
+	acoes := make([]string, 0, len(ligacoes))
+	for acao := range ligacoes {
+		acoes = append(acoes, acao)
+	}
+	sort.Strings(acoes)
+	for _, acao := range acoes {
+		for _, nome := range ligacoes[acao] {
+			tecla, err := teclaInterpretar(nome)
+			if err != nil {
+				return nil, err
+			}
+			if outra, repetida := m.acoes[tecla]; repetida && outra != acao {
+				return nil, erroTraduzido("erro.tecla_repetida", nome, outra, acao)
+			}
+			m.acoes[tecla] = acao
+		}
+	}
+	if len(ligacoes["sair"]) == 0 {
+		return nil, erroTraduzido("erro.sair_sem_tecla")
+	}
+	return m, nil
+}
+
+// Converte o nome de uma tecla da configuração: um único caractere ou o nome
+// de uma tecla especial
+func teclaInterpretar(nome string) (Tecla, error) {
+	if key, ok := teclasEspeciais[strings.ToLower(nome)]; ok {
+		return Tecla{Key: key}, nil
+	}
+	if runas := []rune(nome); len(runas) == 1 {
+		return Tecla{Ch: runas[0]}, nil
+	}
+	return Tecla{}, erroTraduzido("erro.tecla", nome)
+}
+
+// Ação ligada à tecla pressionada. Letras sem ligação própria em maiúscula
+// usam a da minúscula, para que Shift e Caps Lock não atrapalhem.
+func (m *MapaTeclas) Acao(ch rune, key termbox.Key) (EventoTeclado, bool) {
+	tecla := Tecla{Key: key}
+	if ch != 0 {
+		tecla = Tecla{Ch: ch}
+	}
+	acao, ok := m.acoes[tecla]
+	if !ok && ch != 0 {
+		acao, ok = m.acoes[Tecla{Ch: unicode.ToLower(ch)}]
+	}
+	if !ok {
+		return EventoTeclado{}, false
+	}
+	return acoesTeclado[acao], true
+}
+
+// Nome de uma tecla para a linha de ajuda
+func teclaRotulo(nome string) string {
+	switch strings.ToLower(nome) {
+	case "espaco":
+		return traduzir("tecla.espaco")
+	case "esc", "tab", "enter":
+		return strings.ToUpper(nome)
+	case "ctrl+s":
+		return "Ctrl+S"
+	}
+	if strings.HasPrefix(nome, "seta_") {
+		return traduzir("tecla.seta")
+	}
+	return strings.ToUpper(nome)
+}
+
+// Rótulo da primeira tecla de uma ação (vazio se não houver)
+func (m *MapaTeclas) Rotulo(acao string) string {
+	if nomes := m.teclas[acao]; len(nomes) > 0 {
+		return teclaRotulo(nomes[0])
+	}
+	return ""
+}
+
+// Linha de ajuda montada a partir das teclas em uso, com as ações mais
+// importantes primeiro e limitada à largura indicada
+func (m *MapaTeclas) Ajuda(largura int) string {
+	// Os quatro movimentos viram um grupo como "WASD/setas"
+	var letras, outras []string
+	setas := false
+	for _, acao := range []string{"cima", "esquerda", "baixo", "direita"} {
+		for _, nome := range m.teclas[acao] {
+			switch {
+			case strings.HasPrefix(nome, "seta_"):
+				setas = true
+			case len([]rune(nome)) == 1 && len(letras) < 4 && !slices.Contains(letras, strings.ToUpper(nome)):
+				letras = append(letras, strings.ToUpper(nome))
+			default:
+				outras = append(outras, teclaRotulo(nome))
+			}
+		}
+	}
+	var grupo []string
+	if len(letras) == 4 {
+		grupo = append(grupo, strings.Join(letras, ""))
+	}
+	if setas {
+		grupo = append(grupo, traduzir("tecla.setas"))
+	}
+	if len(grupo) == 0 {
+		grupo = outras
+	}
+
+	itens := []string{strings.Join(grupo, "/") + " " + traduzir("ajuda.mover")}
+	for _, acao := range []string{"interagir", "sair", "pausar", "esperar", "armar", "isca", "inventario", "mensagens", "minimapa", "salvar"} {
+		if tecla := m.Rotulo(acao); tecla != "" {
+			itens = append(itens, tecla+" "+traduzir("ajuda."+acao))
+		}
+	}
+
+	ajuda := ""
+	for _, item := range itens {
+		proxima := item
+		if ajuda != "" {
+			proxima = ajuda + " | " + item
+		}
+		if len([]rune(proxima)) > largura {
+			break
+		}
+		ajuda = proxima
+	}
+	return ajuda
+}
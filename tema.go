@@ -0,0 +1,392 @@
+// tema.go - Temas de cores e adaptação da paleta ao terminal
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nsf/termbox-go"
+)
+
+// EstiloTema descreve as cores de um elemento como escritas no tema. Uma cor
+// pode ser "padrao" (a cor do terminal), o nome de uma cor básica, um índice
+// da paleta de 256 cores ("208") ou um valor RGB ("#ff8700").
+type EstiloTema struct {
+	Frente    string   `json:"frente"`
+	Fundo     string   `json:"fundo"`
+	Atributos []string `json:"atributos"` // negrito, sublinhado, esmaecido, invertido
+}
+
+// Formato de um arquivo de tema: um tema pronto como base e os estilos que
+// mudam em relação a ele
+type arquivoTema struct {
+	Base    string                `json:"base"`
+	Estilos map[string]EstiloTema `json:"estilos"`
+}
+
+// Estilo são as cores já convertidas para o modo de cores do terminal
+type Estilo struct {
+	Frente, Fundo Cor
+}
+
+// Tema é um conjunto de estilos pronto para desenhar
+type Tema struct {
+	Nome    string
+	Modo    termbox.OutputMode // modo de cores para o qual os estilos foram convertidos
+	estilos map[string]Estilo
+}
+
+// Diretório onde temas são procurados pelo nome
+const diretorioTemas = "temas"
+
+// Chaves usadas pela interface, além das dos elementos do mapa
+var chavesInterface = []string{"texto", "titulo", "info", "aviso", "perigo", "alerta", "painel"}
+
+// Temas prontos. O padrão reproduz as cores originais do jogo; os demais só
+// dizem o que muda em relação a ele.
+var temasProntos = map[string]arquivoTema{
+	"padrao": {Estilos: map[string]EstiloTema{
+		"personagem": {Frente: "cinza_escuro"},
+		"inimigo":    {Frente: "vermelho"},
+		"parede":     {Frente: "preto", Fundo: "cinza_escuro", Atributos: []string{"negrito", "esmaecido"}},
+		"vegetacao":  {Frente: "verde"},
+		"vazio":      {},
+		"portal":     {Frente: "verde"},
+		"armadilha":  {Frente: "vermelho"},
+		"laco":       {Frente: "amarelo"},
+		"alarme":     {Frente: "magenta"},
+		"teleporte":  {Frente: "ciano"},
+
+		"armadilha_gasta":   {Frente: "cinza_escuro"},
+		"armadilha_jogador": {Frente: "verde", Atributos: []string{"negrito"}},
+		"isca":              {Frente: "amarelo"},
+		"fantasma":          {Frente: "cinza_escuro"},
+		"tesouro":           {Frente: "verde"},
+		"guardiao":          {Frente: "vermelho"},
+		"perseguidor":       {Frente: "vermelho"},
+		"texto":             {Frente: "cinza_escuro"},
+		"titulo":            {Frente: "cinza_escuro", Atributos: []string{"negrito"}},
+		"info":              {Frente: "cinza_escuro"},
+		"aviso":             {Frente: "amarelo"},
+		"perigo":            {Frente: "vermelho", Atributos: []string{"negrito"}},
+		"alerta":            {Frente: "vermelho", Atributos: []string{"negrito"}},
+		"painel":            {Frente: "branco", Fundo: "azul"},
+	}},
+	// Claro sobre fundo preto, com perigos em cores saturadas
+	"alto_contraste": {Base: "padrao", Estilos: map[string]EstiloTema{
+		"personagem": {Frente: "branco_brilhante", Fundo: "preto", Atributos: []string{"negrito"}},
+		"inimigo":    {Frente: "vermelho_claro", Fundo: "preto", Atributos: []string{"negrito"}},
+		"parede":     {Frente: "preto", Fundo: "branco_brilhante"},
+		"vegetacao":  {Frente: "verde_claro", Fundo: "preto"},
+		"vazio":      {Fundo: "preto"},
+		"portal":     {Frente: "ciano_claro", Fundo: "preto", Atributos: []string{"negrito"}},
+		"armadilha":  {Frente: "preto", Fundo: "vermelho_claro", Atributos: []string{"negrito"}},
+		"laco":       {Frente: "preto", Fundo: "amarelo_claro", Atributos: []string{"negrito"}},
+		"alarme":     {Frente: "preto", Fundo: "magenta_claro", Atributos: []string{"negrito"}},
+		"teleporte":  {Frente: "preto", Fundo: "ciano_claro", Atributos: []string{"negrito"}},
+
+		"armadilha_gasta":   {Frente: "branco", Fundo: "preto"},
+		"armadilha_jogador": {Frente: "preto", Fundo: "verde_claro", Atributos: []string{"negrito"}},
+		"isca":              {Frente: "amarelo_claro", Fundo: "preto", Atributos: []string{"negrito"}},
+		"fantasma":          {Frente: "magenta_claro", Fundo: "preto", Atributos: []string{"negrito"}},
+		"tesouro":           {Frente: "amarelo_claro", Fundo: "preto", Atributos: []string{"negrito"}},
+		"guardiao":          {Frente: "preto", Fundo: "amarelo_claro", Atributos: []string{"negrito"}},
+		"perseguidor":       {Frente: "vermelho_claro", Fundo: "preto", Atributos: []string{"negrito"}},
+		"texto":             {Frente: "branco_brilhante"},
+		"titulo":            {Frente: "branco_brilhante", Atributos: []string{"negrito", "sublinhado"}},
+		"info":              {Frente: "branco_brilhante"},
+		"aviso":             {Frente: "amarelo_claro", Atributos: []string{"negrito"}},
+		"perigo":            {Frente: "preto", Fundo: "vermelho_claro", Atributos: []string{"negrito"}},
+		"alerta":            {Frente: "preto", Fundo: "vermelho_claro", Atributos: []string{"negrito"}},
+	}},
+	// Paleta de Okabe e Ito, distinguível com os tipos comuns de daltonismo:
+	// ameaças em vermelhão e laranja, coisas boas em azul e amarelo
+	"daltonico": {Base: "padrao", Estilos: map[string]EstiloTema{
+		"personagem": {Frente: "#ffffff", Atributos: []string{"negrito"}},
+		"inimigo":    {Frente: "#d55e00", Atributos: []string{"negrito"}},
+		"parede":     {Frente: "#000000", Fundo: "#999999"},
+		"vegetacao":  {Frente: "#009e73"},
+		"portal":     {Frente: "#56b4e9", Atributos: []string{"negrito"}},
+		"armadilha":  {Frente: "#e69f00", Atributos: []string{"negrito", "sublinhado"}},
+		"laco":       {Frente: "#e69f00", Atributos: []string{"sublinhado"}},
+		"alarme":     {Frente: "#d55e00", Atributos: []string{"sublinhado"}},
+		"teleporte":  {Frente: "#0072b2", Atributos: []string{"negrito", "sublinhado"}},
+
+		"armadilha_gasta":   {Frente: "#999999"},
+		"armadilha_jogador": {Frente: "#009e73", Atributos: []string{"negrito"}},
+		"isca":              {Frente: "#f0e442"},
+		"fantasma":          {Frente: "#cc79a7"},
+		"tesouro":           {Frente: "#f0e442", Atributos: []string{"negrito"}},
+		"guardiao":          {Frente: "#d55e00", Atributos: []string{"negrito", "sublinhado"}},
+		"perseguidor":       {Frente: "#e69f00"},
+		"texto":             {Frente: "#bbbbbb"},
+		"titulo":            {Frente: "#ffffff", Atributos: []string{"negrito"}},
+		"info":              {Frente: "#bbbbbb"},
+		"aviso":             {Frente: "#f0e442"},
+		"perigo":            {Frente: "#d55e00", Atributos: []string{"negrito"}},
+		"alerta":            {Frente: "#d55e00", Atributos: []string{"negrito"}},
+	}},
+	// Tons terrosos, que aproveitam as 256 cores ou as cores RGB
+	"floresta": {Base: "padrao", Estilos: map[string]EstiloTema{
+		"personagem": {Frente: "#f5deb3", Atributos: []string{"negrito"}},
+		"parede":     {Frente: "#5c4033", Fundo: "#8b6b4a"},
+		"vegetacao":  {Frente: "#2e8b57"},
+		"vazio":      {Fundo: "#1c1c14"},
+		"portal":     {Frente: "#7fffd4", Fundo: "#1c1c14"},
+		"tesouro":    {Frente: "#ffd700", Fundo: "#1c1c14", Atributos: []string{"negrito"}},
+		"fantasma":   {Frente: "#c0c0c0", Fundo: "#1c1c14"},
+		"inimigo":    {Frente: "#cd5c5c", Fundo: "#1c1c14"},
+		"armadilha":  {Frente: "#ff6347", Fundo: "#1c1c14"},
+		"laco":       {Frente: "#daa520", Fundo: "#1c1c14"},
+		"alarme":     {Frente: "#ff4500", Fundo: "#1c1c14"},
+		"teleporte":  {Frente: "#9370db", Fundo: "#1c1c14"},
+
+		"armadilha_gasta":   {Frente: "#6b6b4a", Fundo: "#1c1c14"},
+		"armadilha_jogador": {Frente: "#6b8e23", Fundo: "#1c1c14", Atributos: []string{"negrito"}},
+		"isca":              {Frente: "#cd853f", Fundo: "#1c1c14"},
+		"guardiao":          {Frente: "#b22222", Fundo: "#1c1c14", Atributos: []string{"negrito"}},
+		"perseguidor":       {Frente: "#ff8c00", Fundo: "#1c1c14"},
+		"texto":             {Frente: "#a8a878"},
+		"info":              {Frente: "#a8a878"},
+	}},
+}
+
+// Cores básicas pelo nome, na ordem da paleta de 16 cores do terminal
+var coresBasicas = []string{
+	"preto", "vermelho", "verde", "amarelo", "azul", "magenta", "ciano", "branco",
+	"cinza_escuro", "vermelho_claro", "verde_claro", "amarelo_claro",
+	"azul_claro", "magenta_claro", "ciano_claro", "branco_brilhante",
+}
+
+// Atributos de texto aceitos nos temas
+var atributosTema = map[string]Cor{
+	"negrito":    termbox.AttrBold,
+	"sublinhado": termbox.AttrUnderline,
+	"esmaecido":  termbox.AttrDim,
+	"invertido":  termbox.AttrReverse,
+}
+
+// Modos de cores aceitos na configuração
+var modosCores = map[string]termbox.OutputMode{
+	"basicas": termbox.OutputNormal,
+	"256":     termbox.Output256,
+	"rgb":     termbox.OutputRGB,
+}
+
+// Escolhe o modo de cores: o indicado na configuração ou, em "auto", o que
+// as variáveis COLORTERM e TERM dizem que o terminal suporta
+func modoCoresEscolher(nome string) (termbox.OutputMode, error) {
+	if modo, ok := modosCores[nome]; ok {
+		return modo, nil
+	}
+	if nome != "auto" {
+		return 0, erroTraduzido("erro.modo_cores", nome)
+	}
+	switch colorterm := strings.ToLower(os.Getenv("COLORTERM")); {
+	case colorterm == "truecolor" || colorterm == "24bit":
+		return termbox.OutputRGB, nil
+	case strings.Contains(os.Getenv("TERM"), "256color"):
+		return termbox.Output256, nil
+	}
+	return termbox.OutputNormal, nil
+}
+
+// Carrega um tema pronto pelo nome, ou de um arquivo JSON (um caminho ou um
+// nome procurado em temas/), e converte as cores para o modo indicado
+func temaCarregar(nome string, modo termbox.OutputMode) (*Tema, error) {
+	arq, ok := temasProntos[nome]
+	if !ok {
+		caminho := nome
+		if !strings.HasSuffix(nome, ".json") {
+			caminho = filepath.Join(diretorioTemas, nome+".json")
+		}
+		dados, err := os.ReadFile(caminho)
+		if err != nil {
+			return nil, erroTraduzido("erro.tema", nome, strings.Join(temasDisponiveis(), ", "))
+		}
+		if err := configDecodificar(dados, &arq); err != nil {
+			return nil, fmt.Errorf("%s: %v", caminho, err)
+		}
+		if arq.Base == "" {
+			arq.Base = "padrao"
+		}
+	}
+
+	// Parte dos estilos da base, que precisa ser um tema pronto
+	estilos := make(map[string]EstiloTema)
+	if arq.Base != "" {
+		base, ok := temasProntos[arq.Base]
+		if !ok {
+			return nil, erroTraduzido("erro.tema_base", nome, arq.Base)
+		}
+		for chave, e := range base.Estilos {
+			estilos[chave] = e
+		}
+	}
+	for chave, e := range arq.Estilos {
+		estilos[chave] = e
+	}
+
+	t := &Tema{Nome: nome, Modo: modo, estilos: make(map[string]Estilo)}
+	for chave, e := range estilos {
+		if !chaveTemaValida(chave) {
+			return nil, erroTraduzido("erro.tema_elemento", nome, chave)
+		}
+		estilo, err := estiloConverter(e, modo)
+		if err != nil {
+			return nil, erroTraduzido("erro.tema_estilo", nome, chave, err)
+		}
+		t.estilos[chave] = estilo
+	}
+	return t, nil
+}
+
+// Nomes dos temas prontos, em ordem alfabética
+func temasDisponiveis() []string {
+	nomes := make([]string, 0, len(temasProntos))
+	for nome := range temasProntos {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	return nomes
+}
+
+func chaveTemaValida(chave string) bool {
+	if chaveElementoValida(chave) {
+		return true
+	}
+	for _, c := range chavesInterface {
+		if c == chave {
+			return true
+		}
+	}
+	return false
+}
+
+// Estilo de uma chave do tema; chaves sem estilo usam as cores do terminal
+func (t *Tema) Estilo(chave string) Estilo {
+	return t.estilos[chave]
+}
+
+// Estilo de um elemento do mapa. Elementos sem chave no tema mantêm as
+// próprias cores, que só valem fora do modo RGB.
+func (t *Tema) Elemento(e Elemento) Estilo {
+	if chave, ok := chavesElemento[e.simbolo]; ok {
+		return t.estilos[chave]
+	}
+	if t.Modo == termbox.OutputRGB {
+		return Estilo{}
+	}
+	return Estilo{Frente: e.cor, Fundo: e.corFundo}
+}
+
+// Converte as cores e atributos de um estilo para o modo de cores
+func estiloConverter(e EstiloTema, modo termbox.OutputMode) (Estilo, error) {
+	frente, err := corConverter(e.Frente, modo)
+	if err != nil {
+		return Estilo{}, err
+	}
+	fundo, err := corConverter(e.Fundo, modo)
+	if err != nil {
+		return Estilo{}, err
+	}
+	for _, nome := range e.Atributos {
+		atributo, ok := atributosTema[nome]
+		if !ok {
+			return Estilo{}, erroTraduzido("erro.atributo", nome)
+		}
+		frente |= atributo
+	}
+	return Estilo{Frente: frente, Fundo: fundo}, nil
+}
+
+// Converte uma cor do tema para o modo de cores. Cores que o modo não
+// consegue mostrar viram a mais próxima que ele tem.
+func corConverter(texto string, modo termbox.OutputMode) (Cor, error) {
+	texto = strings.ToLower(strings.TrimSpace(texto))
+	if texto == "" || texto == "padrao" {
+		return termbox.ColorDefault, nil
+	}
+
+	// Cor básica ou índice da paleta de 256 cores
+	indice := -1
+	for i, nome := range coresBasicas {
+		if texto == nome {
+			indice = i
+		}
+	}
+	if n, err := strconv.Atoi(texto); err == nil {
+		if n < 0 || n > 255 {
+			return 0, erroTraduzido("erro.cor_indice", n)
+		}
+		indice = n
+	}
+	if indice >= 0 {
+		switch {
+		case modo == termbox.OutputRGB:
+			r, g, b := paleta256(indice)
+			return termbox.RGBToAttribute(r, g, b), nil
+		case modo == termbox.Output256 || indice < 16:
+			return Cor(indice + 1), nil
+		}
+		r, g, b := paleta256(indice)
+		return Cor(corProxima(r, g, b, 0, 16) + 1), nil
+	}
+
+	// Valor RGB
+	if len(texto) != 7 || texto[0] != '#' {
+		return 0, erroTraduzido("erro.cor", texto)
+	}
+	valor, err := strconv.ParseUint(texto[1:], 16, 32)
+	if err != nil {
+		return 0, erroTraduzido("erro.cor", texto)
+	}
+	r, g, b := uint8(valor>>16), uint8(valor>>8), uint8(valor)
+	switch modo {
+	case termbox.OutputRGB:
+		return termbox.RGBToAttribute(r, g, b), nil
+	case termbox.Output256:
+		// As 16 primeiras cores mudam de terminal para terminal; evita-as
+		return Cor(corProxima(r, g, b, 16, 256) + 1), nil
+	}
+	return Cor(corProxima(r, g, b, 0, 16) + 1), nil
+}
+
+// Valores RGB das cores da paleta de 256 cores do xterm
+func paleta256(i int) (uint8, uint8, uint8) {
+	basicas := [16][3]uint8{
+		{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0}, {0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+		{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+	}
+	switch {
+	case i < 16:
+		return basicas[i][0], basicas[i][1], basicas[i][2]
+	case i < 232:
+		// Cubo 6x6x6
+		niveis := [6]uint8{0, 95, 135, 175, 215, 255}
+		i -= 16
+		return niveis[i/36], niveis[i/6%6], niveis[i%6]
+	}
+	// Tons de cinza
+	v := uint8(8 + 10*(i-232))
+	return v, v, v
+}
+
+// Índice da cor da paleta mais próxima de (r, g, b) entre os índices de e ate
+func corProxima(r, g, b uint8, de, ate int) int {
+	melhor, menor := de, -1
+	for i := de; i < ate; i++ {
+		pr, pg, pb := paleta256(i)
+		dr, dg, db := int(r)-int(pr), int(g)-int(pg), int(b)-int(pb)
+		// Pesos aproximados da sensibilidade do olho a cada componente
+		d := 3*dr*dr + 4*dg*dg + 2*db*db
+		if menor < 0 || d < menor {
+			melhor, menor = i, d
+		}
+	}
+	return melhor
+}